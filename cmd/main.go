@@ -1,26 +1,82 @@
 package main
 
 import (
+	"bulletin-board/internal/ad"
+	"bulletin-board/internal/ad/repository/cached"
+	"bulletin-board/internal/ad/repository/filestore"
 	"bulletin-board/internal/ad/repository/pgstore"
+	adSqlitestore "bulletin-board/internal/ad/repository/sqlitestore"
+	adTraced "bulletin-board/internal/ad/repository/traced"
 	"bulletin-board/internal/ad/service"
 	"bulletin-board/internal/ad/transport/api"
+	adGrpc "bulletin-board/internal/ad/transport/grpcapi"
+	"bulletin-board/internal/graphql"
+	"bulletin-board/internal/memstore"
+	"bulletin-board/internal/middleware"
+	"bulletin-board/internal/migrations"
+	"bulletin-board/internal/openapi"
 	"bulletin-board/internal/redisdb"
+	"bulletin-board/internal/uow"
+	"bulletin-board/internal/user"
+	userCached "bulletin-board/internal/user/cached"
 	userPgstore "bulletin-board/internal/user/pgstore"
 	userServ "bulletin-board/internal/user/service"
+	userSqlitestore "bulletin-board/internal/user/sqlitestore"
+	userTraced "bulletin-board/internal/user/traced"
 	userApi "bulletin-board/internal/user/transport/api"
+	userGrpc "bulletin-board/internal/user/transport/grpcapi"
+	"bulletin-board/internal/validation"
+	"bulletin-board/pkg/cache"
+	"bulletin-board/pkg/logger"
+	bulletinv1 "bulletin-board/pkg/pb/bulletin/v1"
 	"bulletin-board/pkg/postgresql"
+	"bulletin-board/pkg/sqlite"
+	"bulletin-board/pkg/tracing"
 	"context"
+	"errors"
+	"expvar"
+	"fmt"
 	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
-	"log"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 )
 
 func main() {
 	_ = godotenv.Load()
 
-	ctx := context.Background()
+	slog.SetDefault(logger.New(os.Stdout, logger.Config{
+		Level:  os.Getenv("LOG_LEVEL"),
+		Format: os.Getenv("LOG_FORMAT"),
+	}))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Init(ctx, tracing.Config{
+		ServiceName: os.Getenv("OTEL_SERVICE_NAME"),
+		Exporter:    os.Getenv("TRACE_EXPORTER"),
+		Endpoint:    os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+	})
+	if err != nil {
+		fatal("error to init tracing", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			slog.Error("error to flush traces", slog.Any("error", err))
+		}
+	}()
 
 	pc := postgresql.PostgresConfig{
 		Username: os.Getenv("DB_USER"),
@@ -30,28 +86,406 @@ func main() {
 		Database: os.Getenv("DB_DATABASE"),
 	}
 
-	pool, err := postgresql.NewClient(ctx, pc)
-	if err != nil {
-		log.Fatal("error to connect to PostgreSQL")
+	if path := os.Getenv("VALIDATION_RULES"); path != "" {
+		if err := validation.Default().LoadFile(path); err != nil {
+			fatal("error to load validation rules", err)
+		}
+		go validation.Default().Watch(ctx, path, 10*time.Second)
 	}
-	defer pool.Close()
 
-	log.Println("Success connect to PostgreSQL!")
+	var (
+		adRepo   ad.Repository
+		userRepo user.Repository
+		tx       uow.UnitOfWork = uow.NoOp()
+	)
+
+	switch storage := getEnv("STORAGE", "postgres"); storage {
+	case "postgres", "file":
+		pool, err := postgresql.NewClient(ctx, pc)
+		if err != nil {
+			fatal("error to connect to PostgreSQL", err)
+		}
+		defer pool.Close()
+
+		slog.Info("connected to PostgreSQL")
+
+		if err := migrations.Up(ctx, migrations.NewPostgresTarget(pool), migrations.Postgres); err != nil {
+			fatal("error to migrate PostgreSQL", err)
+		}
+
+		var client postgresql.Client = pool
+		if replicas := os.Getenv("DB_REPLICAS"); replicas != "" {
+			router, err := newRouter(ctx, pool, pc, replicas)
+			if err != nil {
+				fatal("error to connect to PostgreSQL replicas", err)
+			}
+			defer router.Close()
+
+			go router.HealthCheck(ctx, 5*time.Second)
+			client = router
+			slog.Info("routing reads to PostgreSQL replicas", slog.String("replicas", replicas))
+		}
+
+		userRepo = userPgstore.NewRepository(client)
+		if storage == "file" {
+			path := getEnv("FILE_STORAGE_PATH", "ads.json")
+			store, err := filestore.NewRepository(path)
+			if err != nil {
+				fatal("error to open file storage", err)
+			}
+			defer func() {
+				if err := store.Close(); err != nil {
+					slog.Error("error to close file storage", slog.Any("error", err))
+				}
+			}()
+			adRepo = store
+			slog.Info("storing ads in file", slog.String("path", path))
+		} else {
+			adRepo = pgstore.NewRepository(client)
+			tx = postgresql.NewTransactor(client)
+		}
+	case "sqlite":
+		path := getEnv("SQLITE_PATH", "bulletin-board.db")
+		db, err := sqlite.NewClient(ctx, path)
+		if err != nil {
+			fatal("error to open SQLite", err)
+		}
+		defer db.Close()
+
+		if err := migrations.Up(ctx, migrations.NewSQLiteTarget(db), migrations.SQLite); err != nil {
+			fatal("error to migrate SQLite", err)
+		}
+		slog.Info("opened SQLite", slog.String("path", path))
+
+		adRepo = adSqlitestore.NewRepository(db)
+		userRepo = userSqlitestore.NewRepository(db)
+		tx = sqlite.NewTransactor(db)
+	case "memory":
+		db := memstore.New()
+		adRepo = db.AdRepository()
+		userRepo = db.UserRepository()
+		slog.Warn("using in-memory storage, data is lost on restart")
+	default:
+		fatal("error to select storage", fmt.Errorf("unknown STORAGE %q", storage))
+	}
+
+	// Redis backs the cache and idempotency keys; connect on first use.
+	redisClient := sync.OnceValues(func() (*redisdb.RedisClient, error) {
+		client, err := redisdb.New(ctx)
+		if err == nil && !client.Degraded() {
+			slog.Info("connected to Redis")
+		}
+		return client, err
+	})
+
+	adCache, err := newCache(ctx, redisClient)
+	if err != nil {
+		fatal("error to set up cache", err)
+	}
+	cacheTTL, err := time.ParseDuration(getEnv("CACHE_TTL", "10m"))
+	if err != nil {
+		fatal("error to parse CACHE_TTL", err)
+	}
+	idempotencyTTL, err := time.ParseDuration(getEnv("IDEMPOTENCY_TTL", "24h"))
+	if err != nil {
+		fatal("error to parse IDEMPOTENCY_TTL", err)
+	}
 
-	redisClient := redisdb.New(ctx)
-	log.Println("Success connect to Redis")
+	tx = uow.WithAfterCommit(tx)
+	adRepo = cached.NewRepository(adTraced.NewRepository(adRepo), adCache, cacheTTL)
+	userRepo = userCached.NewRepository(userTraced.NewRepository(userRepo), adCache, cacheTTL)
 
-	adRepo := pgstore.NewRepository(pool)
-	adService := service.NewService(adRepo, *redisClient)
+	quotas, err := newQuotas()
+	if err != nil {
+		fatal("error to parse quotas", err)
+	}
+	adService := service.NewService(adRepo, userRepo, tx, quotas)
 	adHandler := api.NewHandler(*adService)
 
-	userRepo := userPgstore.NewRepository(pool)
-	userService := userServ.NewService(userRepo)
+	userService := userServ.NewService(userRepo, adRepo, tx)
 	userHandler := userApi.NewHandler(*userService)
 
+	rateLimits, err := newRateLimitRules()
+	if err != nil {
+		fatal("error to parse rate limits", err)
+	}
+	timeouts, writeTimeout, err := newTimeoutRules()
+	if err != nil {
+		fatal("error to parse request timeouts", err)
+	}
+	maxBodyBytes, err := strconv.ParseInt(getEnv("MAX_BODY_BYTES", "1048576"), 10, 64)
+	if err != nil || maxBodyBytes <= 0 {
+		fatal("error to parse MAX_BODY_BYTES", fmt.Errorf("invalid size %q", os.Getenv("MAX_BODY_BYTES")))
+	}
+
+	signingKey := os.Getenv("SINGING_KEY")
 	r := mux.NewRouter()
+	r.Use(middleware.RequestID, middleware.Tracing, middleware.AccessLog, middleware.Recover)
+	var rateLimiter middleware.RateLimiter
+	if len(rateLimits.http) > 0 {
+		client, err := redisClient()
+		if err != nil {
+			fatal("error to connect to Redis", err)
+		}
+		rateLimiter = redisdb.NewRateLimiter(client)
+		r.Use(middleware.RateLimit(rateLimiter, signingKey, rateLimits.http...))
+	}
+	r.Use(middleware.RequireJSON, middleware.LimitBody(maxBodyBytes), middleware.Timeout(timeouts...))
+	r.Use(middleware.DBSession)
+	if idempotencyTTL > 0 {
+		client, err := redisClient()
+		if err != nil {
+			fatal("error to connect to Redis", err)
+		}
+		// A pending key outlives its request by at most the write timeout.
+		lockTTL := writeTimeout
+		if lockTTL <= 0 || lockTTL > idempotencyTTL {
+			lockTTL = idempotencyTTL
+		}
+		r.Use(middleware.Idempotency(redisdb.NewIdempotencyStore(client), lockTTL, idempotencyTTL,
+			middleware.Route(http.MethodPost, "/ads"),
+			middleware.Route(http.MethodPost, "/users"),
+		))
+	}
 	adHandler.NewRouter(r)
 	userHandler.NewRouter(r)
+	openapi.NewRouter(r)
+	graphql.NewHandler(adService, userService, rateLimiter, rateLimits.graphql).NewRouter(r)
+
+	interceptors := []grpc.UnaryServerInterceptor{
+		middleware.UnaryLogging,
+		middleware.UnaryRecover,
+		middleware.UnaryErrors,
+	}
+	if rateLimiter != nil {
+		interceptors = append(interceptors, middleware.UnaryRateLimit(rateLimiter, signingKey, rateLimits.grpc))
+	}
+	interceptors = append(interceptors,
+		middleware.UnaryDBSession,
+		middleware.UnaryAuth(signingKey, append(adGrpc.SecuredMethods, userGrpc.SecuredMethods...)...),
+	)
+	grpcServer := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(interceptors...),
+	)
+	adGrpc.NewServer(adService).Register(grpcServer)
+	userGrpc.NewServer(userService).Register(grpcServer)
+
+	httpServer := &http.Server{Addr: getEnv("HTTP_ADDR", "localhost:8080"), Handler: r, ReadHeaderTimeout: 10 * time.Second}
+	grpcAddr := getEnv("GRPC_ADDR", "localhost:9090")
+
+	// The admin listener serves process metrics, such as the Redis
+	// degraded-mode counters, apart from the public API.
+	admin := http.NewServeMux()
+	admin.Handle("GET /debug/vars", expvar.Handler())
+	adminServer := &http.Server{Addr: getEnv("ADMIN_ADDR", "localhost:8081"), Handler: admin, ReadHeaderTimeout: 10 * time.Second}
+
+	errCh := make(chan error, 3)
+	go func() {
+		slog.Info("starting http server", slog.String("addr", httpServer.Addr))
+		if err := httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			errCh <- fmt.Errorf("http server: %w", err)
+		}
+	}()
+	go func() {
+		slog.Info("starting admin server", slog.String("addr", adminServer.Addr))
+		if err := adminServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			errCh <- fmt.Errorf("admin server: %w", err)
+		}
+	}()
+	go func() {
+		lis, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			errCh <- fmt.Errorf("grpc server: %w", err)
+			return
+		}
+		slog.Info("starting grpc server", slog.String("addr", grpcAddr))
+		if err := grpcServer.Serve(lis); err != nil {
+			errCh <- fmt.Errorf("grpc server: %w", err)
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		slog.Info("shutting down")
+	case err := <-errCh:
+		slog.Error("server stopped", slog.Any("error", err))
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		slog.Error("error to shut down http server", slog.Any("error", err))
+	}
+	if err := adminServer.Shutdown(shutdownCtx); err != nil {
+		slog.Error("error to shut down admin server", slog.Any("error", err))
+	}
+	grpcServer.GracefulStop()
+}
+
+// newCache builds the cache selected by CACHE: redis (default) with an
+// in-process L1 in front of it, lru or none.
+func newCache(ctx context.Context, connect func() (*redisdb.RedisClient, error)) (cache.Cache, error) {
+	switch kind := getEnv("CACHE", "redis"); kind {
+	case "redis":
+		redisClient, err := connect()
+		if err != nil {
+			return nil, err
+		}
+
+		local, err := newLRU()
+		if err != nil {
+			return nil, err
+		}
+		localTTL, err := time.ParseDuration(getEnv("CACHE_LOCAL_TTL", "30s"))
+		if err != nil {
+			return nil, fmt.Errorf("parse CACHE_LOCAL_TTL: %w", err)
+		}
+		tiered := cache.NewTiered(local, redisClient, redisdb.NewInvalidator(redisClient, "cache:invalidate"), localTTL)
+		go tiered.Listen(ctx)
+		return tiered, nil
+	case "lru":
+		return newLRU()
+	case "none":
+		return cache.NoOp(), nil
+	default:
+		return nil, fmt.Errorf("unknown CACHE %q", kind)
+	}
+}
+
+// rateLimitRules holds the rate limits of each API. Rules with the same
+// name share one allowance.
+type rateLimitRules struct {
+	http []middleware.RateLimitRule
+	// grpc maps full method names to their rule.
+	grpc map[string]middleware.RateLimitRule
+	// graphql maps mutations to the rule charged on every call.
+	graphql map[string]middleware.RateLimitRule
+}
+
+// newRateLimitRules reads the per-caller limits of the route groups:
+// RATE_LIMIT_AUTH for sign-in and registration, RATE_LIMIT_WRITE for
+// changes and GraphQL, and RATE_LIMIT_READ for everything else. The
+// gRPC methods that sign in, register or create ads, and each GraphQL
+// sign-in or registration, share the HTTP allowances.
+func newRateLimitRules() (rateLimitRules, error) {
+	auth, err := middleware.ParseLimit(getEnv("RATE_LIMIT_AUTH", "10/m"))
+	if err != nil {
+		return rateLimitRules{}, err
+	}
+	write, err := middleware.ParseLimit(getEnv("RATE_LIMIT_WRITE", "30/m"))
+	if err != nil {
+		return rateLimitRules{}, err
+	}
+	read, err := middleware.ParseLimit(getEnv("RATE_LIMIT_READ", "300/m"))
+	if err != nil {
+		return rateLimitRules{}, err
+	}
+	if !auth.Enabled() && !write.Enabled() && !read.Enabled() {
+		return rateLimitRules{}, nil
+	}
+
+	var rules rateLimitRules
+	rules.grpc = map[string]middleware.RateLimitRule{
+		bulletinv1.UserService_SignIn_FullMethodName:     {Name: "auth", Limit: auth},
+		bulletinv1.UserService_CreateUser_FullMethodName: {Name: "auth", Limit: auth},
+		bulletinv1.AdService_CreateAd_FullMethodName:     {Name: "write", Limit: write},
+	}
+	rules.graphql = map[string]middleware.RateLimitRule{
+		"signIn":     {Name: "auth", Limit: auth},
+		"createUser": {Name: "auth", Limit: auth},
+	}
+	rules.http = []middleware.RateLimitRule{
+		{Name: "auth", Match: middleware.Route(http.MethodPost, "/sign-in"), Limit: auth},
+		{Name: "auth", Match: middleware.Route(http.MethodPost, "/users"), Limit: auth},
+		{Name: "write", Match: middleware.Route(http.MethodPost, "/ads"), Limit: write},
+		{Name: "write", Match: middleware.Route(http.MethodPost, "/graphql"), Limit: write},
+		{Name: "write", Match: middleware.Route(http.MethodPut, "/"), Limit: write},
+		{Name: "write", Match: middleware.Route(http.MethodPatch, "/"), Limit: write},
+		{Name: "write", Match: middleware.Route(http.MethodDelete, "/"), Limit: write},
+		{Name: "read", Match: middleware.Route("", "/"), Limit: read},
+	}
+	return rules, nil
+}
+
+// newTimeoutRules reads how long requests may take: REQUEST_TIMEOUT_READ
+// for GET requests and REQUEST_TIMEOUT_WRITE, which it also returns, for
+// everything else. "0" lifts the limit.
+func newTimeoutRules() ([]middleware.TimeoutRule, time.Duration, error) {
+	read, err := time.ParseDuration(getEnv("REQUEST_TIMEOUT_READ", "5s"))
+	if err != nil {
+		return nil, 0, fmt.Errorf("REQUEST_TIMEOUT_READ: %w", err)
+	}
+	write, err := time.ParseDuration(getEnv("REQUEST_TIMEOUT_WRITE", "10s"))
+	if err != nil {
+		return nil, 0, fmt.Errorf("REQUEST_TIMEOUT_WRITE: %w", err)
+	}
+	return []middleware.TimeoutRule{
+		{Match: middleware.Route(http.MethodGet, "/"), Timeout: read},
+		{Match: middleware.Route("", "/"), Timeout: write},
+	}, write, nil
+}
+
+// newQuotas reads the ad quotas of each user tier from QUOTA_BASIC,
+// QUOTA_VERIFIED and QUOTA_PREMIUM.
+func newQuotas() (service.Quotas, error) {
+	quotas := make(service.Quotas)
+	for tier, fallback := range map[string]string{
+		user.TierBasic:    "10/5",
+		user.TierVerified: "50/20",
+		user.TierPremium:  "200/100",
+	} {
+		key := "QUOTA_" + strings.ToUpper(tier)
+		quota, err := service.ParseQuota(getEnv(key, fallback))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		quotas[tier] = quota
+	}
+	return quotas, nil
+}
+
+func newLRU() (*cache.LRU, error) {
+	size, err := strconv.Atoi(getEnv("CACHE_SIZE", "10000"))
+	if err != nil {
+		return nil, fmt.Errorf("parse CACHE_SIZE: %w", err)
+	}
+	return cache.NewLRU(size), nil
+}
+
+// newRouter connects to the comma-separated host:port replicas using the
+// primary's credentials.
+func newRouter(ctx context.Context, primary *pgxpool.Pool, pc postgresql.PostgresConfig, replicas string) (*postgresql.Router, error) {
+	var pools []*pgxpool.Pool
+	for _, addr := range strings.Split(replicas, ",") {
+		host, port, err := net.SplitHostPort(strings.TrimSpace(addr))
+		if err != nil {
+			return nil, fmt.Errorf("replica %q: %w", addr, err)
+		}
+		rc := pc
+		rc.Host, rc.Port = host, port
+
+		pool, err := postgresql.NewClient(ctx, rc)
+		if err != nil {
+			for _, p := range pools {
+				p.Close()
+			}
+			return nil, err
+		}
+		pools = append(pools, pool)
+	}
+	return postgresql.NewRouter(primary, pools...), nil
+}
+
+func getEnv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
 
-	log.Fatal(http.ListenAndServe("localhost:8080", r))
+func fatal(msg string, err error) {
+	slog.Error(msg, slog.Any("error", err))
+	os.Exit(1)
 }
@@ -1,13 +1,25 @@
 package ad
 
-import "errors"
+import (
+	"bulletin-board/internal/apperror"
+	"time"
+)
 
+// Ad is a listing. Version starts at 1 and is incremented by every
+// update, together with UpdatedAt.
 type Ad struct {
-	ID          int    `json:"id"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Price       int    `json:"price"`
-	UserID      int    `json:"user_id"`
+	ID          int       `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Price       int       `json:"price"`
+	UserID      int       `json:"user_id"`
+	Version     int       `json:"version"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
 
-var ErrForbidden = errors.New("forbidden error")
+var ErrForbidden = apperror.Forbidden("forbidden")
+
+var ErrActiveQuotaExceeded = apperror.Forbidden("active ad quota exceeded")
+
+var ErrDailyQuotaExceeded = apperror.Forbidden("daily ad quota exceeded")
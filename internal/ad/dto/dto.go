@@ -1,20 +1,46 @@
 package dto
 
-import "bulletin-board/internal/ad"
+import (
+	"bulletin-board/internal/ad"
+	"time"
+)
 
 type RequestAd struct {
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Price       int    `json:"price"`
+	Title       string `json:"title" validate:"required,min=3,max=100"`
+	Description string `json:"description" validate:"max=2000"`
+	Price       int    `json:"price" validate:"min=0,max=1000000000"`
 	UserID      int    `json:"user_id"`
 }
 
-type ResponseAd struct {
-	ID          int    `json:"id"`
+// PatchAd is the part of an ad that PATCH requests may change.
+type PatchAd struct {
 	Title       string `json:"title"`
 	Description string `json:"description"`
 	Price       int    `json:"price"`
-	UserID      int    `json:"user_id"`
+}
+
+type ResponseAd struct {
+	ID          int       `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Price       int       `json:"price"`
+	UserID      int       `json:"user_id"`
+	Version     int       `json:"version"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ResponseQuota shows how much of their tier's quota a user has used.
+// A limit of 0 means unlimited.
+type ResponseQuota struct {
+	Tier   string     `json:"tier"`
+	Active QuotaUsage `json:"active"`
+	Daily  QuotaUsage `json:"daily"`
+}
+
+type QuotaUsage struct {
+	Used  int `json:"used"`
+	Limit int `json:"limit"`
 }
 
 func ToDto(ad ad.Ad) ResponseAd {
@@ -24,6 +50,9 @@ func ToDto(ad ad.Ad) ResponseAd {
 		Description: ad.Description,
 		Price:       ad.Price,
 		UserID:      ad.UserID,
+		Version:     ad.Version,
+		CreatedAt:   ad.CreatedAt,
+		UpdatedAt:   ad.UpdatedAt,
 	}
 }
 
@@ -35,3 +64,11 @@ func ToAd(requestAd RequestAd) ad.Ad {
 		UserID:      requestAd.UserID,
 	}
 }
+
+func ToPatch(ad ad.Ad) PatchAd {
+	return PatchAd{
+		Title:       ad.Title,
+		Description: ad.Description,
+		Price:       ad.Price,
+	}
+}
@@ -0,0 +1,266 @@
+package cached
+
+import (
+	"bulletin-board/internal/ad"
+	"bulletin-board/internal/uow"
+	"bulletin-board/pkg/breaker"
+	"bulletin-board/pkg/cache"
+	"bulletin-board/pkg/postgresql"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang.org/x/sync/singleflight"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// negativeTTL bounds how long a missing ad is remembered.
+	negativeTTL = 30 * time.Second
+	// ttlJitter spreads expiries of entries cached at the same moment.
+	ttlJitter = 0.1
+	// beta tunes early refresh; higher values refresh sooner.
+	beta = 1.0
+	// loadTimeout bounds a database load shared by coalesced callers.
+	loadTimeout = 10 * time.Second
+	// generationStripes is the number of invalidation counters ad ids
+	// are spread over.
+	generationStripes = 256
+)
+
+// entry is the cached form of a GetByID result. ExpiresAt and Delta,
+// the time the load took, drive probabilistic early refresh.
+type entry struct {
+	Ad        *ad.Ad `json:"ad,omitempty"`
+	NotFound  bool   `json:"not_found,omitempty"`
+	ExpiresAt int64  `json:"expires_at"`
+	Delta     int64  `json:"delta"`
+}
+
+// generations counts invalidations per stripe of ad ids. A load only
+// keeps its result cached if no invalidation hit its stripe meanwhile,
+// so a read that raced with a committed write cannot outlive it. Only
+// writes through this process are counted.
+type generations [generationStripes]atomic.Uint64
+
+func (g *generations) of(id int) *atomic.Uint64 {
+	return &g[uint(id)%generationStripes]
+}
+
+// repository is a cache-aside decorator: GetByID is served from the
+// cache when possible, and writes invalidate the entry once the
+// surrounding unit of work commits. Concurrent misses for one ad share
+// a single database load, hot entries are refreshed shortly before
+// they expire, and unknown ids are cached briefly. Listings are cached
+// with tags, see Listing. Cache failures are
+// logged and the call falls through to the wrapped repository.
+type repository struct {
+	next  ad.Repository
+	cache cache.Cache
+	ttl   time.Duration
+	group *singleflight.Group
+	gens  *generations
+	now   func() time.Time
+}
+
+func (r repository) GetAll(ctx context.Context) ([]ad.Ad, error) {
+	return Listing(ctx, r.cache, ListingKey("ads", nil), r.ttl, []string{AllAdsTag}, r.next.GetAll)
+}
+
+func (r repository) GetByID(ctx context.Context, ID int) (ad.Ad, error) {
+	// Reads inside a transaction must see the database, not the cache.
+	if uow.InProgress(ctx) {
+		return r.next.GetByID(ctx, ID)
+	}
+
+	key := adKey(ID)
+	if e, ok := r.get(ctx, key); ok {
+		if r.shouldRefresh(e) {
+			go func() { _, _ = r.load(context.WithoutCancel(ctx), ID) }()
+		}
+		return e.result()
+	}
+
+	e, err := r.load(ctx, ID)
+	if err != nil {
+		return ad.Ad{}, err
+	}
+	return e.result()
+}
+
+// GetByUserIDs caches the ad list of a single user as a listing; batch
+// lookups go to the wrapped repository.
+func (r repository) GetByUserIDs(ctx context.Context, userIDs []int) ([]ad.Ad, error) {
+	if len(userIDs) != 1 {
+		return r.next.GetByUserIDs(ctx, userIDs)
+	}
+	key := ListingKey("user_ads", url.Values{"user_id": {strconv.Itoa(userIDs[0])}})
+	return Listing(ctx, r.cache, key, r.ttl, []string{UserAdsTag(userIDs[0])}, func(ctx context.Context) ([]ad.Ad, error) {
+		return r.next.GetByUserIDs(ctx, userIDs)
+	})
+}
+
+func (r repository) Create(ctx context.Context, newAd ad.Ad) (ad.Ad, error) {
+	created, err := r.next.Create(ctx, newAd)
+	if err != nil {
+		return ad.Ad{}, err
+	}
+	// Some stores reuse ids, which may still be cached as not found.
+	r.invalidate(ctx, created.ID)
+	InvalidateListings(ctx, r.cache, AllAdsTag, UserAdsTag(created.UserID))
+	return created, nil
+}
+
+func (r repository) Update(ctx context.Context, newAd ad.Ad, id int) (ad.Ad, error) {
+	updated, err := r.next.Update(ctx, newAd, id)
+	if err != nil {
+		return ad.Ad{}, err
+	}
+	r.invalidate(ctx, id)
+	InvalidateListings(ctx, r.cache, AdTag(id))
+	return updated, nil
+}
+
+func (r repository) Delete(ctx context.Context, id int) error {
+	if err := r.next.Delete(ctx, id); err != nil {
+		return err
+	}
+	r.invalidate(ctx, id)
+	InvalidateListings(ctx, r.cache, AdTag(id))
+	return nil
+}
+
+// CountByUser is not cached: quotas are checked against the database.
+func (r repository) CountByUser(ctx context.Context, userID int, since time.Time) (ad.Usage, error) {
+	return r.next.CountByUser(ctx, userID, since)
+}
+
+func (r repository) get(ctx context.Context, key string) (entry, bool) {
+	data, err := r.cache.Get(ctx, key)
+	if err != nil {
+		if !errors.Is(err, cache.ErrMiss) {
+			logError(ctx, "cache get failed", key, err)
+		}
+		return entry{}, false
+	}
+
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil || (e.Ad == nil && !e.NotFound) {
+		logError(ctx, "cached ad is corrupt", key, err)
+		return entry{}, false
+	}
+	return e, true
+}
+
+// load reads the ad from the wrapped repository and caches the result
+// unless the ad was invalidated during the read. Callers loading the same
+// id at the same time share one read, which runs detached from any
+// single caller's cancellation.
+func (r repository) load(ctx context.Context, ID int) (entry, error) {
+	key := adKey(ID)
+	ch := r.group.DoChan(key, func() (any, error) {
+		ctx, cancel := context.WithTimeout(postgresql.WithPrimary(context.WithoutCancel(ctx)), loadTimeout)
+		defer cancel()
+
+		gen := r.gens.of(ID)
+		started := gen.Load()
+		start := r.now()
+		found, err := r.next.GetByID(ctx, ID)
+		ttl := r.ttl
+		e := entry{Delta: r.now().Sub(start).Milliseconds()}
+		switch {
+		case errors.Is(err, ad.ErrNotFound):
+			e.NotFound = true
+			ttl = min(ttl, negativeTTL)
+		case err != nil:
+			return nil, err
+		default:
+			e.Ad = &found
+		}
+
+		ttl = jitter(ttl)
+		e.ExpiresAt = r.now().Add(ttl).UnixMilli()
+		data, err := json.Marshal(e)
+		if err != nil || gen.Load() != started {
+			return e, nil
+		}
+		if err := r.cache.Set(ctx, key, data, ttl); err != nil {
+			logError(ctx, "cache set failed", key, err)
+		}
+		// An invalidation between the check and the set may have run
+		// its delete first.
+		if gen.Load() != started {
+			if err := r.cache.Delete(ctx, key); err != nil {
+				logError(ctx, "cache delete failed", key, err)
+			}
+		}
+		return e, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return entry{}, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return entry{}, res.Err
+		}
+		return res.Val.(entry), nil
+	}
+}
+
+// shouldRefresh implements probabilistic early expiration (XFetch): the
+// closer an entry is to expiry and the slower it was to load, the more
+// likely a hit triggers a background reload.
+func (r repository) shouldRefresh(e entry) bool {
+	if e.NotFound || e.ExpiresAt == 0 {
+		return false
+	}
+	gap := float64(e.Delta) * beta * -math.Log(1-rand.Float64())
+	return r.now().UnixMilli()+int64(gap) >= e.ExpiresAt
+}
+
+func (r repository) invalidate(ctx context.Context, id int) {
+	uow.AfterCommit(ctx, func(ctx context.Context) {
+		key := adKey(id)
+		r.gens.of(id).Add(1)
+		r.group.Forget(key)
+		if err := r.cache.Delete(ctx, key); err != nil {
+			logError(ctx, "cache delete failed", key, err)
+		}
+	})
+}
+
+func (e entry) result() (ad.Ad, error) {
+	if e.NotFound {
+		return ad.Ad{}, ad.ErrNotFound
+	}
+	return *e.Ad, nil
+}
+
+func jitter(ttl time.Duration) time.Duration {
+	delta := ttlJitter * float64(ttl)
+	return time.Duration(float64(ttl) - delta + rand.Float64()*2*delta)
+}
+
+func adKey(id int) string {
+	return fmt.Sprintf("ad:%d", id)
+}
+
+// logError skips calls rejected by an open circuit breaker, which are
+// expected while the cache is down.
+func logError(ctx context.Context, msg, key string, err error) {
+	if errors.Is(err, breaker.ErrOpen) {
+		return
+	}
+	slog.WarnContext(ctx, msg, slog.String("key", key), slog.Any("error", err))
+}
+
+func NewRepository(next ad.Repository, c cache.Cache, ttl time.Duration) ad.Repository {
+	return repository{next: next, cache: c, ttl: ttl, group: &singleflight.Group{}, gens: &generations{}, now: time.Now}
+}
@@ -0,0 +1,263 @@
+package cached_test
+
+import (
+	"bulletin-board/internal/ad"
+	"bulletin-board/internal/ad/repository/cached"
+	"bulletin-board/internal/ad/repository/repotest"
+	"bulletin-board/internal/memstore"
+	"bulletin-board/internal/uow"
+	"bulletin-board/internal/user"
+	"bulletin-board/pkg/cache"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingRepo counts GetByID calls and can hold them until released.
+type countingRepo struct {
+	ad.Repository
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (r *countingRepo) GetByID(ctx context.Context, ID int) (ad.Ad, error) {
+	r.calls.Add(1)
+	if r.release != nil {
+		<-r.release
+	}
+	return r.Repository.GetByID(ctx, ID)
+}
+
+// stallingRepo holds the first GetByID after reading the ad, until
+// released.
+type stallingRepo struct {
+	ad.Repository
+	once    sync.Once
+	read    chan struct{}
+	release chan struct{}
+}
+
+func (r *stallingRepo) GetByID(ctx context.Context, ID int) (ad.Ad, error) {
+	found, err := r.Repository.GetByID(ctx, ID)
+	r.once.Do(func() {
+		close(r.read)
+		<-r.release
+	})
+	return found, err
+}
+
+func newStore(t *testing.T) (ad.Repository, int) {
+	t.Helper()
+	db := memstore.New()
+	owner, err := db.UserRepository().Create(context.Background(), user.User{
+		Name:     "Owner",
+		Email:    "owner@example.com",
+		Birthday: time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db.AdRepository(), owner.ID
+}
+
+func TestRepository(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) (ad.Repository, int) {
+		store, owner := newStore(t)
+		return cached.NewRepository(store, cache.NewLRU(100), time.Minute), owner
+	})
+}
+
+func TestCacheAside(t *testing.T) {
+	ctx := context.Background()
+	store, owner := newStore(t)
+	c := cache.NewLRU(100)
+	repo := cached.NewRepository(store, c, time.Minute)
+
+	created, err := repo.Create(ctx, ad.Ad{Title: "Bike", Price: 100, UserID: owner})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.GetByID(ctx, created.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// A write that bypasses the decorator is not seen until invalidation.
+	if _, err := store.Update(ctx, ad.Ad{Title: "Car", Price: 100}, created.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := repo.GetByID(ctx, created.ID); got.Title != "Bike" {
+		t.Fatalf("GetByID = %q, want the cached title", got.Title)
+	}
+
+	tx := uow.WithAfterCommit(uow.NoOp())
+	err = tx.Do(ctx, func(ctx context.Context) error {
+		if _, err := repo.Update(ctx, ad.Ad{Title: "Boat", Price: 100}, created.ID); err != nil {
+			return err
+		}
+		if c.Len() != 1 {
+			t.Fatal("cache was invalidated before the unit of work committed")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := repo.GetByID(ctx, created.ID); got.Title != "Boat" {
+		t.Fatalf("GetByID after update = %q, want Boat", got.Title)
+	}
+}
+
+func TestConcurrentMissesShareOneLoad(t *testing.T) {
+	ctx := context.Background()
+	store, owner := newStore(t)
+	created, err := store.Create(ctx, ad.Ad{Title: "Bike", Price: 100, UserID: owner})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counting := &countingRepo{Repository: store, release: make(chan struct{})}
+	repo := cached.NewRepository(counting, cache.NewLRU(100), time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got, err := repo.GetByID(ctx, created.ID); err != nil || got.Title != "Bike" {
+				t.Errorf("GetByID = %+v, %v", got, err)
+			}
+		}()
+	}
+	for counting.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(counting.release)
+	wg.Wait()
+
+	if n := counting.calls.Load(); n != 1 {
+		t.Fatalf("repository was read %d times, want 1", n)
+	}
+}
+
+func TestUpdateDuringLoad(t *testing.T) {
+	ctx := context.Background()
+	store, owner := newStore(t)
+	created, err := store.Create(ctx, ad.Ad{Title: "Bike", Price: 100, UserID: owner})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stalling := &stallingRepo{Repository: store, read: make(chan struct{}), release: make(chan struct{})}
+	repo := cached.NewRepository(stalling, cache.NewLRU(100), time.Minute)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = repo.GetByID(ctx, created.ID)
+	}()
+	<-stalling.read
+	if _, err := repo.Update(ctx, ad.Ad{Title: "Boat", Price: 100}, created.ID); err != nil {
+		t.Fatal(err)
+	}
+	close(stalling.release)
+	<-done
+
+	if got, _ := repo.GetByID(ctx, created.ID); got.Title != "Boat" {
+		t.Fatalf("GetByID after a load raced with an update = %q, want Boat", got.Title)
+	}
+}
+
+func TestNegativeCaching(t *testing.T) {
+	ctx := context.Background()
+	store, owner := newStore(t)
+	counting := &countingRepo{Repository: store}
+	repo := cached.NewRepository(counting, cache.NewLRU(100), time.Minute)
+
+	for i := 0; i < 3; i++ {
+		if _, err := repo.GetByID(ctx, 1); !errors.Is(err, ad.ErrNotFound) {
+			t.Fatalf("GetByID error = %v, want ad.ErrNotFound", err)
+		}
+	}
+	if n := counting.calls.Load(); n != 1 {
+		t.Fatalf("repository was read %d times for a missing ad, want 1", n)
+	}
+
+	created, err := repo.Create(ctx, ad.Ad{Title: "Bike", Price: 100, UserID: owner})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.ID != 1 {
+		t.Fatalf("created id = %d, want 1", created.ID)
+	}
+	if _, err := repo.GetByID(ctx, created.ID); err != nil {
+		t.Fatalf("GetByID after Create = %v, want the new ad", err)
+	}
+}
+
+func TestListingInvalidation(t *testing.T) {
+	ctx := context.Background()
+	store, owner := newStore(t)
+	repo := cached.NewRepository(store, cache.NewLRU(100), time.Minute)
+
+	bike, err := repo.Create(ctx, ad.Ad{Title: "Bike", Price: 100, UserID: owner})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ads, _ := repo.GetAll(ctx); len(ads) != 1 {
+		t.Fatalf("GetAll returned %d ads, want 1", len(ads))
+	}
+
+	// A write that bypasses the decorator shows the listing is cached.
+	if _, err := store.Create(ctx, ad.Ad{Title: "Car", Price: 100, UserID: owner}); err != nil {
+		t.Fatal(err)
+	}
+	if ads, _ := repo.GetAll(ctx); len(ads) != 1 {
+		t.Fatalf("GetAll returned %d ads, want the cached listing", len(ads))
+	}
+
+	if _, err := repo.Update(ctx, ad.Ad{Title: "Boat", Price: 100}, bike.ID); err != nil {
+		t.Fatal(err)
+	}
+	ads, _ := repo.GetAll(ctx)
+	if len(ads) != 2 {
+		t.Fatalf("GetAll after update returned %d ads, want 2", len(ads))
+	}
+
+	if err := repo.Delete(ctx, bike.ID); err != nil {
+		t.Fatal(err)
+	}
+	if ads, _ := repo.GetAll(ctx); len(ads) != 1 {
+		t.Fatalf("GetAll after delete returned %d ads, want 1", len(ads))
+	}
+}
+
+func TestUserAdsListingInvalidation(t *testing.T) {
+	ctx := context.Background()
+	store, owner := newStore(t)
+	repo := cached.NewRepository(store, cache.NewLRU(100), time.Minute)
+
+	if list, _ := repo.GetByUserIDs(ctx, []int{owner}); len(list) != 0 {
+		t.Fatalf("GetByUserIDs = %v, want empty", list)
+	}
+
+	bike, err := repo.Create(ctx, ad.Ad{Title: "Bike", Price: 100, UserID: owner})
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, _ := repo.GetByUserIDs(ctx, []int{owner})
+	if len(list) != 1 {
+		t.Fatalf("GetByUserIDs after create returned %d ads, want 1", len(list))
+	}
+
+	if _, err := repo.Update(ctx, ad.Ad{Title: "Boat", Price: 100, UserID: owner}, bike.ID); err != nil {
+		t.Fatal(err)
+	}
+	list, _ = repo.GetByUserIDs(ctx, []int{owner})
+	if len(list) != 1 || list[0].Title != "Boat" {
+		t.Fatalf("GetByUserIDs after update = %+v, want the new title", list)
+	}
+}
@@ -0,0 +1,84 @@
+package cached
+
+import (
+	"bulletin-board/internal/ad"
+	"bulletin-board/internal/uow"
+	"bulletin-board/pkg/cache"
+	"bulletin-board/pkg/postgresql"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// maxListingTTL caps how long a listing is cached. Listings are
+// invalidated by tag on every ad write, so the cap only limits the
+// damage of a load racing with a write.
+const maxListingTTL = time.Minute
+
+// Tags attached to cached listings. A listing carries the tag of every
+// ad it contains, so any change to an ad drops the listings showing it.
+const AllAdsTag = "ads"
+
+func AdTag(id int) string {
+	return fmt.Sprintf("ad:%d", id)
+}
+
+func UserAdsTag(userID int) string {
+	return fmt.Sprintf("user:%d:ads", userID)
+}
+
+// ListingKey builds the cache key of a listing from its normalized
+// query parameters, so equivalent queries share one entry.
+func ListingKey(kind string, params url.Values) string {
+	return "list:" + kind + "?" + params.Encode()
+}
+
+// Listing returns the ads cached under key, or calls load and caches its
+// result tagged with tags plus the tag of each returned ad. Loads read
+// from the primary, since a replica's lag would be cached with them.
+// Inside a unit of work the cache is bypassed.
+func Listing(ctx context.Context, c cache.Cache, key string, ttl time.Duration, tags []string, load func(ctx context.Context) ([]ad.Ad, error)) ([]ad.Ad, error) {
+	if uow.InProgress(ctx) {
+		return load(ctx)
+	}
+
+	if data, err := c.Get(ctx, key); err == nil {
+		var ads []ad.Ad
+		if err := json.Unmarshal(data, &ads); err == nil && ads != nil {
+			return ads, nil
+		}
+		logError(ctx, "cached listing is corrupt", key, err)
+	} else if !errors.Is(err, cache.ErrMiss) {
+		logError(ctx, "cache get failed", key, err)
+	}
+
+	ads, err := load(postgresql.WithPrimary(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(ads)
+	if err != nil {
+		return ads, nil
+	}
+	for _, a := range ads {
+		tags = append(tags, AdTag(a.ID))
+	}
+	if err := c.SetWithTags(ctx, key, data, jitter(min(ttl, maxListingTTL)), tags...); err != nil {
+		logError(ctx, "cache set failed", key, err)
+	}
+	return ads, nil
+}
+
+// InvalidateListings drops the listings carrying any of tags once the
+// surrounding unit of work commits.
+func InvalidateListings(ctx context.Context, c cache.Cache, tags ...string) {
+	uow.AfterCommit(ctx, func(ctx context.Context) {
+		if _, err := c.InvalidateTags(ctx, tags...); err != nil {
+			logError(ctx, "cache tag invalidation failed", fmt.Sprint(tags), err)
+		}
+	})
+}
@@ -1,174 +1,370 @@
-package fileStore
+package filestore
 
 import (
 	"bulletin-board/internal/ad"
 	"context"
-	"encoding/json"
 	"errors"
 	"io"
+	"log/slog"
 	"os"
-	"path/filepath"
+	"slices"
 	"sync"
+	"time"
 )
 
+// Repository is an ad.Repository that keeps its files open until Close.
+type Repository interface {
+	ad.Repository
+	io.Closer
+}
+
+// defaultCompactEvery is the number of log records after which the log
+// is folded into a new snapshot.
+const defaultCompactEvery = 1000
+
+// fileStore keeps ads in a snapshot file plus an append-only log of
+// changes made since the snapshot. Both are loaded into memory once; on
+// every operation only records appended by other processes are replayed.
+// An flock on a sidecar lock file serialises access between processes.
 type fileStore struct {
-	filePath string
-	mu       sync.Mutex
+	filePath     string
+	lock         *fileLock
+	log          *os.File
+	compactEvery int
+
+	mu           sync.Mutex
+	items        map[int]ad.Ad
+	creations    []creation
+	nextID       int
+	snapshotInfo os.FileInfo
+	logOffset    int64
+	logRecords   int
 }
 
-func (f fileStore) GetAll(ctx context.Context) ([]ad.Ad, error) {
-	f.mu.Lock()
-	defer f.mu.Unlock()
-	return f.readAll()
+func (f *fileStore) GetAll(ctx context.Context) ([]ad.Ad, error) {
+	var ads []ad.Ad
+	err := f.read(func() {
+		ads = make([]ad.Ad, 0, len(f.items))
+		for _, item := range f.items {
+			ads = append(ads, item)
+		}
+		slices.SortFunc(ads, func(a, b ad.Ad) int { return a.ID - b.ID })
+	})
+	return ads, err
 }
 
-func (f fileStore) GetByID(ctx context.Context, ID int) (ad.Ad, error) {
-	f.mu.Lock()
-	defer f.mu.Unlock()
+func (f *fileStore) GetByUserIDs(ctx context.Context, userIDs []int) ([]ad.Ad, error) {
+	var ads []ad.Ad
+	err := f.read(func() {
+		ads = make([]ad.Ad, 0)
+		for _, item := range f.items {
+			if slices.Contains(userIDs, item.UserID) {
+				ads = append(ads, item)
+			}
+		}
+		slices.SortFunc(ads, func(a, b ad.Ad) int { return a.ID - b.ID })
+	})
+	return ads, err
+}
+
+func (f *fileStore) GetByID(ctx context.Context, ID int) (ad.Ad, error) {
+	var (
+		item ad.Ad
+		ok   bool
+	)
+	if err := f.read(func() { item, ok = f.items[ID] }); err != nil {
+		return ad.Ad{}, err
+	}
+	if !ok {
+		return ad.Ad{}, ad.ErrNotFound
+	}
+	return item, nil
+}
 
-	items, err := f.readAll()
+func (f *fileStore) Create(ctx context.Context, newAd ad.Ad) (ad.Ad, error) {
+	err := f.write(func() (record, error) {
+		newAd.ID = f.nextID
+		newAd.Version = 1
+		newAd.CreatedAt = time.Now().UTC()
+		newAd.UpdatedAt = newAd.CreatedAt
+		return record{Op: opPut, ID: newAd.ID, Ad: &newAd}, nil
+	})
 	if err != nil {
 		return ad.Ad{}, err
 	}
+	return newAd, nil
+}
 
-	for _, item := range items {
-		if item.ID == ID {
-			return item, nil
+func (f *fileStore) Update(ctx context.Context, newAd ad.Ad, id int) (ad.Ad, error) {
+	var updated ad.Ad
+	err := f.write(func() (record, error) {
+		item, ok := f.items[id]
+		if !ok {
+			return record{}, ad.ErrNotFound
+		}
+		if newAd.Version != 0 && newAd.Version != item.Version {
+			return record{}, ad.ErrVersionMismatch
 		}
+		updateItem(&item, &newAd)
+		updated = item
+		return record{Op: opPut, ID: id, Ad: &item}, nil
+	})
+	if err != nil {
+		return ad.Ad{}, err
 	}
-	return ad.Ad{}, ad.ErrNotFound
+	return updated, nil
 }
 
-func (f fileStore) Create(ctx context.Context, newAd ad.Ad) (ad.Ad, error) {
-	f.mu.Lock()
-	defer f.mu.Unlock()
-	items, err := f.readAll()
+func (f *fileStore) Delete(ctx context.Context, ID int) error {
+	return f.write(func() (record, error) {
+		if _, ok := f.items[ID]; !ok {
+			return record{}, ad.ErrNotFound
+		}
+		return record{Op: opDelete, ID: ID}, nil
+	})
+}
+
+func (f *fileStore) CountByUser(ctx context.Context, userID int, since time.Time) (ad.Usage, error) {
+	var usage ad.Usage
+	err := f.read(func() {
+		for _, item := range f.items {
+			if item.UserID == userID {
+				usage.Active++
+			}
+		}
+		for _, c := range f.creations {
+			if c.UserID == userID && !c.CreatedAt.Before(since) {
+				usage.Created++
+			}
+		}
+	})
+	return usage, err
+}
+
+func NewRepository(path string) (Repository, error) {
+	lock, err := openLock(path + ".lock")
 	if err != nil {
-		return ad.Ad{}, err
+		return nil, err
 	}
 
-	var maxId int
-	for _, item := range items {
-		maxId = max(maxId, item.ID)
+	log, err := os.OpenFile(path+".log", os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		lock.Close()
+		return nil, err
 	}
-	maxId++
-	newAd.ID = maxId
 
-	items = append(items, newAd)
-	if err := f.writeAtomic(items); err != nil {
-		return ad.Ad{}, err
+	f := &fileStore{filePath: path, lock: lock, log: log, compactEvery: defaultCompactEvery}
+	if err := f.load(); err != nil {
+		f.Close()
+		return nil, err
 	}
-	return newAd, nil
+	return f, nil
 }
 
-func (f fileStore) Update(ctx context.Context, newAd ad.Ad, id int) (ad.Ad, error) {
+func (f *fileStore) load() error {
+	if err := f.lock.Lock(); err != nil {
+		return err
+	}
+	defer f.lock.Unlock()
+
+	return f.reload(true)
+}
+
+// Close closes the log and releases the lock file. The repository must
+// not be used afterwards.
+func (f *fileStore) Close() error {
 	f.mu.Lock()
 	defer f.mu.Unlock()
-	items, err := f.readAll()
-	if err != nil {
-		return ad.Ad{}, err
-	}
 
-	updated := false
-	for i := range items {
-		if items[i].ID == newAd.ID {
-			updateItem(&items[i], &newAd)
-			updated = true
-			break
-		}
-	}
+	return errors.Join(f.log.Close(), f.lock.Close())
+}
 
-	if !updated {
-		return ad.Ad{}, ad.ErrNotFound
+func (f *fileStore) read(fn func()) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.lock.RLock(); err != nil {
+		return err
 	}
+	defer f.lock.Unlock()
 
-	return newAd, f.writeAtomic(items)
+	if err := f.refresh(false); err != nil {
+		return err
+	}
+	fn()
+	return nil
 }
 
-func (f fileStore) Delete(ctx context.Context, ID int) error {
+// write builds a record from the current index, appends it to the log
+// and only then applies it in memory.
+func (f *fileStore) write(fn func() (record, error)) error {
 	f.mu.Lock()
 	defer f.mu.Unlock()
-	items, err := f.readAll()
-	if err != nil {
+
+	if err := f.lock.Lock(); err != nil {
 		return err
 	}
+	defer f.lock.Unlock()
 
-	newItems := make([]ad.Ad, 0, len(items))
+	if err := f.refresh(true); err != nil {
+		return err
+	}
 
-	deleted := false
-	for _, item := range items {
-		if item.ID == ID {
-			deleted = true
-			continue
-		}
-		newItems = append(newItems, item)
+	rec, err := fn()
+	if err != nil {
+		return err
 	}
 
-	if !deleted {
-		return ad.ErrNotFound
+	n, err := appendRecord(f.log, rec)
+	if err != nil {
+		return err
 	}
+	f.apply(rec)
+	f.logOffset += n
+	f.logRecords++
 
-	return f.writeAtomic(newItems)
+	if f.logRecords >= f.compactEvery {
+		if err := f.compact(); err != nil {
+			slog.Error("filestore compaction failed", slog.String("path", f.filePath), slog.Any("error", err))
+		}
+	}
+	return nil
 }
 
-func NewRepository(path string) ad.Repository {
-	return &fileStore{filePath: path}
-}
+// refresh catches up with changes made by other processes: a replaced
+// snapshot means a compaction happened and everything is reloaded,
+// otherwise only new log records are replayed. Truncating a torn tail
+// needs the exclusive lock, so readers merely skip it.
+func (f *fileStore) refresh(exclusive bool) error {
+	info, err := os.Stat(f.filePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if !sameFile(f.snapshotInfo, info) {
+		return f.reload(exclusive)
+	}
 
-func (f *fileStore) writeAtomic(items []ad.Ad) error {
-	dir := filepath.Dir(f.filePath)
-	tmp, err := os.CreateTemp(dir, "tmp.json")
+	logInfo, err := f.log.Stat()
 	if err != nil {
 		return err
 	}
+	if logInfo.Size() < f.logOffset {
+		return f.reload(exclusive)
+	}
+	if logInfo.Size() == f.logOffset {
+		return nil
+	}
+	return f.replay(exclusive)
+}
 
-	defer func() {
-		_ = os.Remove(tmp.Name())
-	}()
-
-	enc := json.NewEncoder(tmp)
-	enc.SetIndent("", "  ")
-	if err := enc.Encode(items); err != nil {
+func (f *fileStore) reload(exclusive bool) error {
+	snap, info, err := readSnapshot(f.filePath)
+	if err != nil {
 		return err
 	}
 
-	if err := tmp.Sync(); err != nil {
-		return err
+	f.items = make(map[int]ad.Ad, len(snap.Ads))
+	f.creations = snap.Creations
+	f.nextID = max(snap.NextID, 1)
+	for _, item := range snap.Ads {
+		f.items[item.ID] = withVersion(item)
+		f.nextID = max(f.nextID, item.ID+1)
 	}
+	f.snapshotInfo = info
+	f.logOffset = 0
+	f.logRecords = 0
+
+	return f.replay(exclusive)
+}
 
-	if err := tmp.Close(); err != nil {
+func (f *fileStore) replay(exclusive bool) error {
+	offset, torn, err := replayLog(f.log, f.logOffset, func(rec record) {
+		f.apply(rec)
+		f.logRecords++
+	})
+	if err != nil {
 		return err
 	}
+	f.logOffset = offset
 
-	return os.Rename(tmp.Name(), f.filePath)
+	if torn && exclusive {
+		slog.Warn("filestore dropping torn log tail", slog.String("path", f.log.Name()), slog.Int64("offset", offset))
+		if err := f.log.Truncate(offset); err != nil {
+			return err
+		}
+		return f.log.Sync()
+	}
+	return nil
 }
 
-func (f *fileStore) readAll() ([]ad.Ad, error) {
-	file, err := os.Open(f.filePath)
-	if errors.Is(err, os.ErrNotExist) {
-		return []ad.Ad{}, nil
+func (f *fileStore) apply(rec record) {
+	switch rec.Op {
+	case opPut:
+		if rec.Ad != nil {
+			// Ids are never reused, so a put past nextID creates an ad.
+			if rec.ID >= f.nextID {
+				f.creations = append(f.creations, creation{UserID: rec.Ad.UserID, CreatedAt: rec.Ad.CreatedAt})
+			}
+			f.items[rec.ID] = withVersion(*rec.Ad)
+		}
+	case opDelete:
+		delete(f.items, rec.ID)
 	}
-	if err != nil {
-		return nil, err
+	f.nextID = max(f.nextID, rec.ID+1)
+}
+
+// compact writes the index as a new snapshot and empties the log. If the
+// process dies in between, replaying the old log over the new snapshot
+// is harmless because records are idempotent.
+func (f *fileStore) compact() error {
+	list := make([]ad.Ad, 0, len(f.items))
+	for _, item := range f.items {
+		list = append(list, item)
 	}
+	slices.SortFunc(list, func(a, b ad.Ad) int { return a.ID - b.ID })
 
-	defer file.Close()
+	cutoff := time.Now().Add(-ad.CreationHistory)
+	creations := slices.DeleteFunc(slices.Clone(f.creations), func(c creation) bool {
+		return c.CreatedAt.Before(cutoff)
+	})
 
-	var items []ad.Ad
+	info, err := writeSnapshot(f.filePath, snapshot{NextID: f.nextID, Ads: list, Creations: creations})
+	if err != nil {
+		return err
+	}
+	f.snapshotInfo = info
+	f.creations = creations
 
-	dec := json.NewDecoder(file)
-	if err := dec.Decode(&items); err != nil {
-		if errors.Is(err, io.EOF) {
-			return []ad.Ad{}, nil
-		}
-		return nil, err
+	if err := f.log.Truncate(0); err != nil {
+		return err
+	}
+	if err := f.log.Sync(); err != nil {
+		return err
 	}
-	return items, nil
+	f.logOffset = 0
+	f.logRecords = 0
+	return nil
+}
+
+func sameFile(a, b os.FileInfo) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return os.SameFile(a, b) && a.ModTime().Equal(b.ModTime()) && a.Size() == b.Size()
 }
 
 func updateItem(oldItem, newItem *ad.Ad) {
 	oldItem.Title = newItem.Title
 	oldItem.Description = newItem.Description
 	oldItem.Price = newItem.Price
-	oldItem.Contact = newItem.Contact
+	oldItem.Version++
+	oldItem.UpdatedAt = time.Now().UTC()
+}
+
+// withVersion gives ads written before versioning the initial version.
+func withVersion(item ad.Ad) ad.Ad {
+	if item.Version == 0 {
+		item.Version = 1
+	}
+	return item
 }
@@ -0,0 +1,103 @@
+package filestore_test
+
+import (
+	"bulletin-board/internal/ad"
+	"bulletin-board/internal/ad/repository/filestore"
+	"bulletin-board/internal/ad/repository/repotest"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func open(t *testing.T, path string) filestore.Repository {
+	t.Helper()
+	repo, err := filestore.NewRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	return repo
+}
+
+func TestRepository(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) (ad.Repository, int) {
+		return open(t, filepath.Join(t.TempDir(), "ads.json")), 1
+	})
+}
+
+func TestSharedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ads.json")
+	ctx := context.Background()
+
+	first := open(t, path)
+	second := open(t, path)
+
+	created, err := first.Create(ctx, ad.Ad{Title: "bike", UserID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := second.GetByID(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("second store does not see the write: %v", err)
+	}
+	if got != created {
+		t.Fatalf("GetByID = %+v, want %+v", got, created)
+	}
+
+	other, err := second.Create(ctx, ad.Ad{Title: "lamp", UserID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.ID == created.ID {
+		t.Fatalf("id %d assigned twice across stores", other.ID)
+	}
+
+	all, err := open(t, path).GetAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("GetAll after reopen returned %d ads, want 2", len(all))
+	}
+}
+
+func TestClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ads.json")
+	repo, err := filestore.NewRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := repo.GetAll(context.Background()); err == nil {
+		t.Fatal("GetAll after Close succeeded")
+	}
+}
+
+func TestOpenFailureClosesFiles(t *testing.T) {
+	fds, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Skip("cannot count open files:", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "ads.json")
+	if err := os.WriteFile(path, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := filestore.NewRepository(path); err == nil {
+			t.Fatal("NewRepository with a broken snapshot succeeded")
+		}
+	}
+
+	after, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after) > len(fds) {
+		t.Fatalf("%d files open after failed opens, %d before", len(after), len(fds))
+	}
+}
@@ -0,0 +1,27 @@
+//go:build !unix
+
+package filestore
+
+// fileLock is a no-op where flock(2) is unavailable; the store is then
+// only safe within a single process.
+type fileLock struct{}
+
+func openLock(path string) (*fileLock, error) {
+	return &fileLock{}, nil
+}
+
+func (l *fileLock) Lock() error {
+	return nil
+}
+
+func (l *fileLock) RLock() error {
+	return nil
+}
+
+func (l *fileLock) Unlock() error {
+	return nil
+}
+
+func (l *fileLock) Close() error {
+	return nil
+}
@@ -0,0 +1,47 @@
+//go:build unix
+
+package filestore
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileLock is an advisory flock(2) lock shared by every process that
+// opens the same store.
+type fileLock struct {
+	file *os.File
+}
+
+func openLock(path string) (*fileLock, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &fileLock{file: file}, nil
+}
+
+func (l *fileLock) Lock() error {
+	return l.flock(syscall.LOCK_EX)
+}
+
+func (l *fileLock) RLock() error {
+	return l.flock(syscall.LOCK_SH)
+}
+
+func (l *fileLock) Unlock() error {
+	return l.flock(syscall.LOCK_UN)
+}
+
+func (l *fileLock) Close() error {
+	return l.file.Close()
+}
+
+func (l *fileLock) flock(how int) error {
+	for {
+		err := syscall.Flock(int(l.file.Fd()), how)
+		if err != syscall.EINTR {
+			return err
+		}
+	}
+}
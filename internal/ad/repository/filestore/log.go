@@ -0,0 +1,157 @@
+package filestore
+
+import (
+	"bufio"
+	"bulletin-board/internal/ad"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// Log records are framed as
+//
+//	length uint32 | crc32c(payload) uint32 | payload (JSON)
+//
+// in little endian. A crash in the middle of an append can only leave an
+// invalid record at the end of the log, such as one cut short or padded
+// with zeros by the file system; such a tail is dropped during recovery.
+// An invalid record followed by valid ones means the log is corrupt, and
+// replaying it fails rather than losing the records after it.
+
+const (
+	headerSize   = 8
+	maxRecordLen = 1 << 20
+
+	opPut    = "put"
+	opDelete = "delete"
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+var errCorruptLog = errors.New("corrupt log")
+
+type record struct {
+	Op string `json:"op"`
+	ID int    `json:"id"`
+	Ad *ad.Ad `json:"ad,omitempty"`
+}
+
+func encodeRecord(rec record) ([]byte, error) {
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, headerSize+len(payload))
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(buf[4:8], crc32.Checksum(payload, crcTable))
+	copy(buf[headerSize:], payload)
+	return buf, nil
+}
+
+// replayLog applies the records of file starting at offset and returns
+// the offset just past the last valid record and whether a torn tail
+// follows it.
+func replayLog(file *os.File, offset int64, apply func(record)) (int64, bool, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return offset, false, err
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return offset, false, err
+	}
+	r := bufio.NewReader(file)
+
+	header := make([]byte, headerSize)
+	for {
+		rec, length, err := readRecord(r, header)
+		switch {
+		case errors.Is(err, io.EOF):
+			return offset, false, nil
+		case errors.Is(err, errCorruptLog):
+			torn, tailErr := isTornTail(file, offset, info.Size())
+			if tailErr != nil {
+				return offset, false, tailErr
+			}
+			if !torn {
+				return offset, false, fmt.Errorf("%w: invalid record at offset %d of %s", errCorruptLog, offset, file.Name())
+			}
+			return offset, true, nil
+		case err != nil:
+			return offset, false, err
+		}
+
+		apply(rec)
+		offset += int64(headerSize) + int64(length)
+	}
+}
+
+// readRecord reads the next record. It returns io.EOF at the end of the
+// log and errCorruptLog for a short or invalid record.
+func readRecord(r io.Reader, header []byte) (record, uint32, error) {
+	if _, err := io.ReadFull(r, header); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return record{}, 0, errCorruptLog
+		}
+		return record{}, 0, err
+	}
+
+	length := binary.LittleEndian.Uint32(header[0:4])
+	sum := binary.LittleEndian.Uint32(header[4:8])
+	if length == 0 || length > maxRecordLen {
+		return record{}, 0, errCorruptLog
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return record{}, 0, errCorruptLog
+		}
+		return record{}, 0, err
+	}
+	if crc32.Checksum(payload, crcTable) != sum {
+		return record{}, 0, errCorruptLog
+	}
+
+	var rec record
+	if err := json.Unmarshal(payload, &rec); err != nil {
+		return record{}, 0, errCorruptLog
+	}
+	return rec, length, nil
+}
+
+// isTornTail reports whether the invalid record at offset can be left
+// from an interrupted append, that is whether no valid record follows.
+func isTornTail(file *os.File, offset, size int64) (bool, error) {
+	tail := make([]byte, size-offset)
+	if _, err := file.ReadAt(tail, offset); err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+
+	header := make([]byte, headerSize)
+	for i := 1; i+headerSize < len(tail); i++ {
+		if _, _, err := readRecord(bytes.NewReader(tail[i:]), header); err == nil {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func appendRecord(file *os.File, rec record) (int64, error) {
+	buf, err := encodeRecord(rec)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := file.Write(buf); err != nil {
+		return 0, fmt.Errorf("append log record: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return 0, fmt.Errorf("sync log: %w", err)
+	}
+	return int64(len(buf)), nil
+}
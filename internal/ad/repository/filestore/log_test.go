@@ -0,0 +1,231 @@
+package filestore
+
+import (
+	"bulletin-board/internal/ad"
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestStore(t *testing.T, path string) *fileStore {
+	t.Helper()
+	repo, err := NewRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	return repo.(*fileStore)
+}
+
+func TestRecoverTornTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ads.json")
+	ctx := context.Background()
+
+	f := newTestStore(t, path)
+	first, err := f.Create(ctx, ad.Ad{Title: "kept", UserID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Create(ctx, ad.Ad{Title: "torn", UserID: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path + ".log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(path+".log", info.Size()-3); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened := newTestStore(t, path)
+	all, err := reopened.GetAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 1 || all[0] != first {
+		t.Fatalf("GetAll after torn write = %+v, want [%+v]", all, first)
+	}
+
+	if _, err := reopened.Create(ctx, ad.Ad{Title: "after", UserID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	all, err = newTestStore(t, path).GetAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("GetAll after recovery and append returned %d ads, want 2", len(all))
+	}
+}
+
+func TestRejectCorruptRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ads.json")
+	ctx := context.Background()
+
+	f := newTestStore(t, path)
+	if _, err := f.Create(ctx, ad.Ad{Title: "kept", UserID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Create(ctx, ad.Ad{Title: "flipped", UserID: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path + ".log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-2] ^= 0xff
+	if err := os.WriteFile(path+".log", data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := newTestStore(t, path).GetAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 1 || all[0].Title != "kept" {
+		t.Fatalf("GetAll with corrupt record = %+v, want only the first ad", all)
+	}
+}
+
+func TestCorruptRecordBeforeValidOnes(t *testing.T) {
+	ctx := context.Background()
+
+	for name, corrupt := range map[string]func(data []byte){
+		"payload": func(data []byte) { data[headerSize+2] ^= 0xff },
+		"length":  func(data []byte) { binary.LittleEndian.PutUint32(data[0:4], 1<<30) },
+		"zeroed":  func(data []byte) { clear(data[:headerSize]) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "ads.json")
+			f := newTestStore(t, path)
+			for _, title := range []string{"first", "second", "third"} {
+				if _, err := f.Create(ctx, ad.Ad{Title: title, UserID: 1}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			data, err := os.ReadFile(path + ".log")
+			if err != nil {
+				t.Fatal(err)
+			}
+			corrupt(data)
+			if err := os.WriteFile(path+".log", data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := NewRepository(path); !errors.Is(err, errCorruptLog) {
+				t.Fatalf("NewRepository = %v, want errCorruptLog", err)
+			}
+			if after, err := os.ReadFile(path + ".log"); err != nil || len(after) != len(data) {
+				t.Fatalf("log shrank from %d to %d bytes (%v), want it left alone", len(data), len(after), err)
+			}
+		})
+	}
+}
+
+func TestRecoverZeroedTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ads.json")
+	ctx := context.Background()
+
+	f := newTestStore(t, path)
+	if _, err := f.Create(ctx, ad.Ad{Title: "kept", UserID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path + ".log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(path+".log", info.Size()+64); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := newTestStore(t, path).GetAll(ctx)
+	if err != nil || len(all) != 1 {
+		t.Fatalf("GetAll with a zeroed tail = %+v, %v, want the one ad", all, err)
+	}
+	if after, err := os.Stat(path + ".log"); err != nil || after.Size() != info.Size() {
+		t.Fatalf("zeroed tail was not dropped: %v, %v", after, err)
+	}
+}
+
+func TestCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ads.json")
+	ctx := context.Background()
+
+	f := newTestStore(t, path)
+	f.compactEvery = 5
+
+	var last ad.Ad
+	for i := 0; i < 12; i++ {
+		created, err := f.Create(ctx, ad.Ad{Title: "item", UserID: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		last = created
+	}
+	if err := f.Delete(ctx, last.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if f.logRecords >= f.compactEvery {
+		t.Fatalf("log holds %d records, compaction did not run", f.logRecords)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("snapshot not written: %v", err)
+	}
+
+	reopened := newTestStore(t, path)
+	all, err := reopened.GetAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 11 {
+		t.Fatalf("GetAll after compaction returned %d ads, want 11", len(all))
+	}
+	usage, err := reopened.CountByUser(ctx, 1, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage != (ad.Usage{Active: 11, Created: 12}) {
+		t.Fatalf("CountByUser after compaction = %+v, want the deleted ad still counted as created", usage)
+	}
+
+	created, err := reopened.Create(ctx, ad.Ad{Title: "next", UserID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.ID <= last.ID {
+		t.Fatalf("Create reused id %d after delete of %d", created.ID, last.ID)
+	}
+}
+
+func TestLegacySnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ads.json")
+	legacy := `[{"id": 3, "title": "old", "description": "", "price": 1, "user_id": 1}]`
+	if err := os.WriteFile(path, []byte(legacy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f := newTestStore(t, path)
+	got, err := f.GetByID(context.Background(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "old" {
+		t.Fatalf("GetByID = %+v", got)
+	}
+
+	created, err := f.Create(context.Background(), ad.Ad{Title: "new", UserID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.ID != 4 {
+		t.Fatalf("Create assigned id %d, want 4", created.ID)
+	}
+}
@@ -0,0 +1,101 @@
+package filestore
+
+import (
+	"bulletin-board/internal/ad"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type snapshot struct {
+	NextID    int        `json:"next_id"`
+	Ads       []ad.Ad    `json:"ads"`
+	Creations []creation `json:"creations,omitempty"`
+}
+
+// creation records that a user created an ad, so that CountByUser still
+// counts it once the ad is deleted.
+type creation struct {
+	UserID    int       `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// readSnapshot also accepts the plain JSON array written by earlier
+// versions of the store.
+func readSnapshot(path string) (snapshot, os.FileInfo, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return snapshot{}, nil, nil
+	}
+	if err != nil {
+		return snapshot{}, nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return snapshot{}, nil, err
+	}
+
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(file); err != nil {
+		return snapshot{}, nil, err
+	}
+	data := bytes.TrimSpace(buf.Bytes())
+
+	var snap snapshot
+	switch {
+	case len(data) == 0:
+	case data[0] == '[':
+		err = json.Unmarshal(data, &snap.Ads)
+	default:
+		err = json.Unmarshal(data, &snap)
+	}
+	if err != nil {
+		return snapshot{}, nil, fmt.Errorf("decode %s: %w", path, err)
+	}
+	return snap, info, nil
+}
+
+func writeSnapshot(path string, snap snapshot) (os.FileInfo, error) {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	enc := json.NewEncoder(tmp)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(snap); err != nil {
+		_ = tmp.Close()
+		return nil, err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return nil, err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, err
+	}
+
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		_ = d.Close()
+	}
+
+	return os.Stat(path)
+}
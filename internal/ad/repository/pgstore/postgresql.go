@@ -2,11 +2,14 @@ package pgstore
 
 import (
 	"bulletin-board/internal/ad"
+	"bulletin-board/internal/apperror"
 	"bulletin-board/pkg/postgresql"
 	"context"
 	"errors"
 	"fmt"
 	"github.com/jackc/pgx/v5/pgconn"
+	"log/slog"
+	"time"
 )
 
 type repository struct {
@@ -15,9 +18,10 @@ type repository struct {
 
 func (r repository) GetAll(ctx context.Context) ([]ad.Ad, error) {
 	q := `
-		select id, title, description, price, user_id 
-		from ads`
-	rows, err := r.client.Query(ctx, q)
+		select id, title, description, price, user_id, version, created_at, updated_at
+		from ads
+		order by id`
+	rows, err := r.conn(ctx).Query(ctx, q)
 	if err != nil {
 		return nil, err
 	}
@@ -28,7 +32,7 @@ func (r repository) GetAll(ctx context.Context) ([]ad.Ad, error) {
 	for rows.Next() {
 		var ad ad.Ad
 
-		err = rows.Scan(&ad.ID, &ad.Title, &ad.Description, &ad.Price, &ad.UserID)
+		err = rows.Scan(&ad.ID, &ad.Title, &ad.Description, &ad.Price, &ad.UserID, &ad.Version, &ad.CreatedAt, &ad.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -43,32 +47,84 @@ func (r repository) GetAll(ctx context.Context) ([]ad.Ad, error) {
 	return ads, nil
 }
 
+func (r repository) GetByUserIDs(ctx context.Context, userIDs []int) ([]ad.Ad, error) {
+	q := `
+		select id, title, description, price, user_id, version, created_at, updated_at
+		from ads
+		where user_id = any($1)
+		order by id`
+	rows, err := r.conn(ctx).Query(ctx, q, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ads := make([]ad.Ad, 0)
+
+	for rows.Next() {
+		var a ad.Ad
+		if err = rows.Scan(&a.ID, &a.Title, &a.Description, &a.Price, &a.UserID, &a.Version, &a.CreatedAt, &a.UpdatedAt); err != nil {
+			return nil, err
+		}
+		ads = append(ads, a)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ads, nil
+}
+
 func (r repository) GetByID(ctx context.Context, ID int) (ad.Ad, error) {
 	q := `
-		select id, title, description, price, user_id 
+		select id, title, description, price, user_id, version, created_at, updated_at
 		from ads 
 		where id = $1`
 	var returnedAd ad.Ad
-	err := r.client.QueryRow(ctx, q, ID).Scan(&returnedAd.ID, &returnedAd.Title, &returnedAd.Description, &returnedAd.Price, &returnedAd.UserID)
+	err := r.conn(ctx).QueryRow(ctx, q, ID).Scan(&returnedAd.ID, &returnedAd.Title, &returnedAd.Description, &returnedAd.Price, &returnedAd.UserID, &returnedAd.Version, &returnedAd.CreatedAt, &returnedAd.UpdatedAt)
 	if err != nil {
+		if postgresql.IsNoRows(err) {
+			return ad.Ad{}, ad.ErrNotFound
+		}
 		return ad.Ad{}, err
 	}
 	return returnedAd, nil
 }
 
 func (r repository) Create(ctx context.Context, newAd ad.Ad) (ad.Ad, error) {
+	// The creation is recorded, and history past ad.CreationHistory
+	// pruned, in the same statement.
 	q := `
-		insert into ads (title, description, price, user_id) 
-		values ($1, $2, $3, $4)
-		returning id, title, description, price, user_id`
-	err := r.client.QueryRow(ctx, q, newAd.Title, newAd.Description, newAd.Price, newAd.UserID).
-		Scan(&newAd.ID, &newAd.Title, &newAd.Description, &newAd.Price, &newAd.UserID)
+		with created as (
+			insert into ads (title, description, price, user_id, version, created_at, updated_at)
+			values ($1, $2, $3, $4, 1, $5, $5)
+			returning id, title, description, price, user_id, version, created_at, updated_at
+		), recorded as (
+			insert into ad_creations (user_id, created_at)
+			select user_id, created_at from created
+		), pruned as (
+			delete from ad_creations
+			where user_id = $4 and created_at < $6
+		)
+		select id, title, description, price, user_id, version, created_at, updated_at
+		from created`
+	createdAt := now()
+	err := r.conn(ctx).QueryRow(ctx, q, newAd.Title, newAd.Description, newAd.Price, newAd.UserID, createdAt, createdAt.Add(-ad.CreationHistory)).
+		Scan(&newAd.ID, &newAd.Title, &newAd.Description, &newAd.Price, &newAd.UserID, &newAd.Version, &newAd.CreatedAt, &newAd.UpdatedAt)
 	if err != nil {
+		if postgresql.IsForeignKeyViolation(err) {
+			return ad.Ad{}, apperror.Wrap(ad.ErrUnknownUser, err)
+		}
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
-			newErr := fmt.Errorf("SQL error: %s, Detail: %s, Where: %s, Code: %s, SQL State: %s", pgErr.Message, pgErr.Detail, pgErr.Where, pgErr.Code, pgErr.SQLState())
-			fmt.Println(newErr)
-			return ad.Ad{}, newErr
+			slog.ErrorContext(ctx, "sql error",
+				slog.String("message", pgErr.Message),
+				slog.String("detail", pgErr.Detail),
+				slog.String("where", pgErr.Where),
+				slog.String("code", pgErr.Code),
+			)
+			return ad.Ad{}, fmt.Errorf("create: %w", err)
 		}
 		return ad.Ad{}, err
 	}
@@ -81,22 +137,43 @@ func (r repository) Update(ctx context.Context, newAd ad.Ad, id int) (ad.Ad, err
 		set
 			title = $1,
 			description = $2,
-			price = $3
-		where id = $4
-		returning id, title, description, price, user_id`
-	err := r.client.QueryRow(ctx, q, newAd.Title, newAd.Description, newAd.Price, id).
-		Scan(&newAd.ID, &newAd.Title, &newAd.Description, &newAd.Price, &newAd.UserID)
+			price = $3,
+			version = version + 1,
+			updated_at = $4
+		where id = $5 and ($6::integer = 0 or version = $6)
+		returning id, title, description, price, user_id, version, created_at, updated_at`
+	err := r.conn(ctx).QueryRow(ctx, q, newAd.Title, newAd.Description, newAd.Price, now(), id, newAd.Version).
+		Scan(&newAd.ID, &newAd.Title, &newAd.Description, &newAd.Price, &newAd.UserID, &newAd.Version, &newAd.CreatedAt, &newAd.UpdatedAt)
 	if err != nil {
+		if postgresql.IsNoRows(err) {
+			return ad.Ad{}, r.missing(ctx, id, newAd.Version)
+		}
 		return ad.Ad{}, err
 	}
 	return newAd, nil
 }
 
+// missing explains why a conditional update matched no row.
+func (r repository) missing(ctx context.Context, id, version int) error {
+	if version == 0 {
+		return ad.ErrNotFound
+	}
+	var exists bool
+	err := r.conn(ctx).QueryRow(ctx, `select exists (select 1 from ads where id = $1)`, id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ad.ErrNotFound
+	}
+	return ad.ErrVersionMismatch
+}
+
 func (r repository) Delete(ctx context.Context, id int) error {
 	q := `
 		delete from ads
 		where id = $1`
-	tag, err := r.client.Exec(ctx, q, id)
+	tag, err := r.conn(ctx).Exec(ctx, q, id)
 	if err != nil {
 		return err
 	}
@@ -108,6 +185,26 @@ func (r repository) Delete(ctx context.Context, id int) error {
 	return nil
 }
 
+func (r repository) CountByUser(ctx context.Context, userID int, since time.Time) (ad.Usage, error) {
+	q := `
+		select
+			(select count(*) from ads where user_id = $1),
+			(select count(*) from ad_creations where user_id = $1 and created_at >= $2)`
+	var usage ad.Usage
+	err := r.conn(ctx).QueryRow(ctx, q, userID, since).Scan(&usage.Active, &usage.Created)
+	return usage, err
+}
+
 func NewRepository(client postgresql.Client) ad.Repository {
 	return &repository{client: client}
 }
+
+// now is the modification time stored by writes, at the precision
+// Postgres keeps.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+func (r repository) conn(ctx context.Context) postgresql.Client {
+	return postgresql.Conn(ctx, r.client)
+}
@@ -0,0 +1,42 @@
+package pgstore_test
+
+import (
+	"bulletin-board/internal/ad"
+	"bulletin-board/internal/ad/repository/pgstore"
+	"bulletin-board/internal/ad/repository/repotest"
+	"context"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"os"
+	"testing"
+)
+
+// TestRepository runs against the database in TEST_DATABASE_URL and
+// truncates the ads and users tables.
+func TestRepository(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+
+	repotest.Run(t, func(t *testing.T) (ad.Repository, int) {
+		if _, err := pool.Exec(ctx, "truncate ads, users restart identity cascade"); err != nil {
+			t.Fatal(err)
+		}
+		var userID int
+		err := pool.QueryRow(ctx, `
+			insert into users (name, email, password, birthday, contact)
+			values ('Owner', 'owner@example.com', 'hash', '1990-01-01', '')
+			returning id`).Scan(&userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pgstore.NewRepository(pool), userID
+	})
+}
@@ -0,0 +1,297 @@
+// Package repotest is a conformance suite that every ad.Repository
+// implementation must pass.
+package repotest
+
+import (
+	"bulletin-board/internal/ad"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Factory returns an empty repository and the id of an existing user
+// that new ads may reference.
+type Factory func(t *testing.T) (repo ad.Repository, userID int)
+
+func Run(t *testing.T, newRepo Factory) {
+	t.Run("CreateAssignsIDs", func(t *testing.T) {
+		repo, userID := newRepo(t)
+		ctx := context.Background()
+
+		first := mustCreate(t, repo, ad.Ad{Title: "bike", Description: "red", Price: 100, UserID: userID})
+		second := mustCreate(t, repo, ad.Ad{Title: "lamp", Price: 5, UserID: userID})
+
+		if first.ID <= 0 || second.ID <= 0 || first.ID == second.ID {
+			t.Fatalf("expected distinct positive ids, got %d and %d", first.ID, second.ID)
+		}
+
+		got, err := repo.GetByID(ctx, first.ID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		if !equal(got, first) {
+			t.Fatalf("GetByID = %+v, want %+v", got, first)
+		}
+	})
+
+	t.Run("GetByIDNotFound", func(t *testing.T) {
+		repo, _ := newRepo(t)
+
+		_, err := repo.GetByID(context.Background(), 424242)
+		if !errors.Is(err, ad.ErrNotFound) {
+			t.Fatalf("GetByID error = %v, want ad.ErrNotFound", err)
+		}
+	})
+
+	t.Run("GetAll", func(t *testing.T) {
+		repo, userID := newRepo(t)
+		ctx := context.Background()
+
+		ads, err := repo.GetAll(ctx)
+		if err != nil {
+			t.Fatalf("GetAll: %v", err)
+		}
+		if ads == nil || len(ads) != 0 {
+			t.Fatalf("GetAll on empty repository = %#v, want empty non-nil slice", ads)
+		}
+
+		want := map[int]ad.Ad{}
+		for _, title := range []string{"a", "b", "c"} {
+			created := mustCreate(t, repo, ad.Ad{Title: title, UserID: userID})
+			want[created.ID] = created
+		}
+
+		ads, err = repo.GetAll(ctx)
+		if err != nil {
+			t.Fatalf("GetAll: %v", err)
+		}
+		if len(ads) != len(want) {
+			t.Fatalf("GetAll returned %d ads, want %d", len(ads), len(want))
+		}
+		for i, a := range ads {
+			if !equal(want[a.ID], a) {
+				t.Fatalf("GetAll returned %+v, want %+v", a, want[a.ID])
+			}
+			if i > 0 && ads[i-1].ID >= a.ID {
+				t.Fatalf("GetAll returned ids %d before %d, want them in ascending order", ads[i-1].ID, a.ID)
+			}
+		}
+	})
+
+	t.Run("GetByUserIDs", func(t *testing.T) {
+		repo, userID := newRepo(t)
+		ctx := context.Background()
+
+		none, err := repo.GetByUserIDs(ctx, []int{userID})
+		if err != nil || none == nil || len(none) != 0 {
+			t.Fatalf("GetByUserIDs without ads = %#v, %v, want empty non-nil slice", none, err)
+		}
+
+		first := mustCreate(t, repo, ad.Ad{Title: "a", UserID: userID})
+		second := mustCreate(t, repo, ad.Ad{Title: "b", UserID: userID})
+		got, err := repo.GetByUserIDs(ctx, []int{userID, 424242})
+		if err != nil {
+			t.Fatalf("GetByUserIDs: %v", err)
+		}
+		if len(got) != 2 || !equal(got[0], first) || !equal(got[1], second) {
+			t.Fatalf("GetByUserIDs = %+v, want [%+v %+v]", got, first, second)
+		}
+
+		if err := repo.Delete(ctx, first.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		got, err = repo.GetByUserIDs(ctx, []int{userID})
+		if err != nil || len(got) != 1 || !equal(got[0], second) {
+			t.Fatalf("GetByUserIDs after Delete = %+v, %v, want [%+v]", got, err, second)
+		}
+		if other, err := repo.GetByUserIDs(ctx, []int{424242}); err != nil || len(other) != 0 {
+			t.Fatalf("GetByUserIDs of another user = %+v, %v, want none", other, err)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo, userID := newRepo(t)
+		ctx := context.Background()
+		created := mustCreate(t, repo, ad.Ad{Title: "old", Description: "old", Price: 1, UserID: userID})
+
+		updated, err := repo.Update(ctx, ad.Ad{Title: "new", Description: "new", Price: 2}, created.ID)
+		if err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		want := ad.Ad{ID: created.ID, Title: "new", Description: "new", Price: 2, UserID: userID, Version: 2, CreatedAt: created.CreatedAt, UpdatedAt: updated.UpdatedAt}
+		if !equal(updated, want) {
+			t.Fatalf("Update = %+v, want %+v", updated, want)
+		}
+		if updated.UpdatedAt.Before(created.UpdatedAt) {
+			t.Fatalf("Update moved updated_at back from %v to %v", created.UpdatedAt, updated.UpdatedAt)
+		}
+
+		got, err := repo.GetByID(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		if !equal(got, want) {
+			t.Fatalf("GetByID after Update = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("UpdateNotFound", func(t *testing.T) {
+		repo, _ := newRepo(t)
+
+		_, err := repo.Update(context.Background(), ad.Ad{Title: "x"}, 424242)
+		if !errors.Is(err, ad.ErrNotFound) {
+			t.Fatalf("Update error = %v, want ad.ErrNotFound", err)
+		}
+	})
+
+	t.Run("UpdateVersion", func(t *testing.T) {
+		repo, userID := newRepo(t)
+		ctx := context.Background()
+		created := mustCreate(t, repo, ad.Ad{Title: "old", UserID: userID})
+
+		updated, err := repo.Update(ctx, ad.Ad{Title: "new", Version: created.Version}, created.ID)
+		if err != nil {
+			t.Fatalf("Update with current version: %v", err)
+		}
+		if updated.Version != created.Version+1 {
+			t.Fatalf("Update version = %d, want %d", updated.Version, created.Version+1)
+		}
+
+		_, err = repo.Update(ctx, ad.Ad{Title: "lost", Version: created.Version}, created.ID)
+		if !errors.Is(err, ad.ErrVersionMismatch) {
+			t.Fatalf("Update with stale version error = %v, want ad.ErrVersionMismatch", err)
+		}
+		got, err := repo.GetByID(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		if !equal(got, updated) {
+			t.Fatalf("stale Update changed the ad to %+v", got)
+		}
+
+		_, err = repo.Update(ctx, ad.Ad{Title: "x", Version: 1}, 424242)
+		if !errors.Is(err, ad.ErrNotFound) {
+			t.Fatalf("Update of missing ad with version error = %v, want ad.ErrNotFound", err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repo, userID := newRepo(t)
+		ctx := context.Background()
+		created := mustCreate(t, repo, ad.Ad{Title: "gone", UserID: userID})
+
+		if err := repo.Delete(ctx, created.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if _, err := repo.GetByID(ctx, created.ID); !errors.Is(err, ad.ErrNotFound) {
+			t.Fatalf("GetByID after Delete error = %v, want ad.ErrNotFound", err)
+		}
+		if err := repo.Delete(ctx, created.ID); !errors.Is(err, ad.ErrNotFound) {
+			t.Fatalf("second Delete error = %v, want ad.ErrNotFound", err)
+		}
+	})
+
+	t.Run("CountByUser", func(t *testing.T) {
+		repo, userID := newRepo(t)
+		ctx := context.Background()
+		start := time.Now().Add(-time.Minute)
+
+		usage, err := repo.CountByUser(ctx, userID, start)
+		if err != nil {
+			t.Fatalf("CountByUser: %v", err)
+		}
+		if usage != (ad.Usage{}) {
+			t.Fatalf("CountByUser without ads = %+v, want zero", usage)
+		}
+
+		first := mustCreate(t, repo, ad.Ad{Title: "one", UserID: userID})
+		mustCreate(t, repo, ad.Ad{Title: "two", UserID: userID})
+
+		for since, want := range map[time.Time]ad.Usage{
+			start:                       {Active: 2, Created: 2},
+			time.Now().Add(time.Minute): {Active: 2, Created: 0},
+		} {
+			usage, err := repo.CountByUser(ctx, userID, since)
+			if err != nil {
+				t.Fatalf("CountByUser: %v", err)
+			}
+			if usage != want {
+				t.Fatalf("CountByUser since %v = %+v, want %+v", since, usage, want)
+			}
+		}
+
+		if err := repo.Delete(ctx, first.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if usage, err := repo.CountByUser(ctx, userID, start); err != nil || usage != (ad.Usage{Active: 1, Created: 2}) {
+			t.Fatalf("CountByUser after Delete = %+v, %v, want one active ad and both creations", usage, err)
+		}
+		mustCreate(t, repo, ad.Ad{Title: "three", UserID: userID})
+		if usage, err := repo.CountByUser(ctx, userID, start); err != nil || usage != (ad.Usage{Active: 2, Created: 3}) {
+			t.Fatalf("CountByUser after another Create = %+v, %v, want two active ads and three creations", usage, err)
+		}
+		if usage, err := repo.CountByUser(ctx, userID+1, start); err != nil || usage != (ad.Usage{}) {
+			t.Fatalf("CountByUser of another user = %+v, %v, want zero", usage, err)
+		}
+	})
+
+	t.Run("ConcurrentCreate", func(t *testing.T) {
+		repo, userID := newRepo(t)
+		const n = 20
+
+		var wg sync.WaitGroup
+		ids := make(chan int, n)
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				created, err := repo.Create(context.Background(), ad.Ad{Title: "item", UserID: userID})
+				if err != nil {
+					t.Errorf("Create: %v", err)
+					return
+				}
+				ids <- created.ID
+			}()
+		}
+		wg.Wait()
+		close(ids)
+
+		seen := map[int]bool{}
+		for id := range ids {
+			if seen[id] {
+				t.Fatalf("id %d assigned twice", id)
+			}
+			seen[id] = true
+		}
+	})
+}
+
+func mustCreate(t *testing.T, repo ad.Repository, a ad.Ad) ad.Ad {
+	t.Helper()
+	created, err := repo.Create(context.Background(), a)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	a.ID = created.ID
+	a.Version = 1
+	a.CreatedAt = created.CreatedAt
+	a.UpdatedAt = created.UpdatedAt
+	if !equal(created, a) {
+		t.Fatalf("Create = %+v, want %+v", created, a)
+	}
+	if created.CreatedAt.IsZero() || !created.UpdatedAt.Equal(created.CreatedAt) {
+		t.Fatalf("Create set created_at %v and updated_at %v, want the same instant", created.CreatedAt, created.UpdatedAt)
+	}
+	return created
+}
+
+func equal(a, b ad.Ad) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) || !a.UpdatedAt.Equal(b.UpdatedAt) {
+		return false
+	}
+	a.CreatedAt, b.CreatedAt = time.Time{}, time.Time{}
+	a.UpdatedAt, b.UpdatedAt = time.Time{}, time.Time{}
+	return a == b
+}
@@ -0,0 +1,216 @@
+package sqlitestore
+
+import (
+	"bulletin-board/internal/ad"
+	"bulletin-board/internal/apperror"
+	"bulletin-board/pkg/sqlite"
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+type repository struct {
+	db *sql.DB
+	tx *sqlite.Transactor
+}
+
+func (r repository) GetAll(ctx context.Context) ([]ad.Ad, error) {
+	q := `
+		select id, title, description, price, user_id, version, created_at, updated_at
+		from ads
+		order by id`
+	rows, err := r.conn(ctx).QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ads := make([]ad.Ad, 0)
+
+	for rows.Next() {
+		var ad ad.Ad
+
+		err = rows.Scan(&ad.ID, &ad.Title, &ad.Description, &ad.Price, &ad.UserID, &ad.Version, sqlite.ScanTime(&ad.CreatedAt), sqlite.ScanTime(&ad.UpdatedAt))
+		if err != nil {
+			return nil, err
+		}
+
+		ads = append(ads, ad)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ads, nil
+}
+
+func (r repository) GetByUserIDs(ctx context.Context, userIDs []int) ([]ad.Ad, error) {
+	ads := make([]ad.Ad, 0)
+	if len(userIDs) == 0 {
+		return ads, nil
+	}
+
+	args := make([]any, len(userIDs))
+	for i, id := range userIDs {
+		args[i] = id
+	}
+	q := `
+		select id, title, description, price, user_id, version, created_at, updated_at
+		from ads
+		where user_id in (` + strings.TrimSuffix(strings.Repeat("?, ", len(userIDs)), ", ") + `)
+		order by id`
+	rows, err := r.conn(ctx).QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a ad.Ad
+		if err = rows.Scan(&a.ID, &a.Title, &a.Description, &a.Price, &a.UserID, &a.Version, sqlite.ScanTime(&a.CreatedAt), sqlite.ScanTime(&a.UpdatedAt)); err != nil {
+			return nil, err
+		}
+		ads = append(ads, a)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ads, nil
+}
+
+func (r repository) GetByID(ctx context.Context, ID int) (ad.Ad, error) {
+	q := `
+		select id, title, description, price, user_id, version, created_at, updated_at
+		from ads
+		where id = ?`
+	var returnedAd ad.Ad
+	err := r.conn(ctx).QueryRowContext(ctx, q, ID).Scan(&returnedAd.ID, &returnedAd.Title, &returnedAd.Description, &returnedAd.Price, &returnedAd.UserID, &returnedAd.Version, sqlite.ScanTime(&returnedAd.CreatedAt), sqlite.ScanTime(&returnedAd.UpdatedAt))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ad.Ad{}, ad.ErrNotFound
+		}
+		return ad.Ad{}, err
+	}
+	return returnedAd, nil
+}
+
+// Create also records the creation, and prunes history past
+// ad.CreationHistory, in the same transaction: SQLite has no
+// data-modifying common table expressions.
+func (r repository) Create(ctx context.Context, newAd ad.Ad) (ad.Ad, error) {
+	err := r.tx.Do(ctx, func(ctx context.Context) error {
+		q := `
+			insert into ads (title, description, price, user_id, version, created_at, updated_at)
+			values (?, ?, ?, ?, 1, ?, ?)
+			returning id, title, description, price, user_id, version, created_at, updated_at`
+		createdAt := time.Now()
+		now := sqlite.FormatTime(createdAt)
+		err := r.conn(ctx).QueryRowContext(ctx, q, newAd.Title, newAd.Description, newAd.Price, newAd.UserID, now, now).
+			Scan(&newAd.ID, &newAd.Title, &newAd.Description, &newAd.Price, &newAd.UserID, &newAd.Version, sqlite.ScanTime(&newAd.CreatedAt), sqlite.ScanTime(&newAd.UpdatedAt))
+		if err != nil {
+			return err
+		}
+
+		q = `
+			insert into ad_creations (user_id, created_at)
+			values (?, ?)`
+		if _, err := r.conn(ctx).ExecContext(ctx, q, newAd.UserID, now); err != nil {
+			return err
+		}
+
+		q = `
+			delete from ad_creations
+			where user_id = ? and julianday(created_at) < julianday(?)`
+		_, err = r.conn(ctx).ExecContext(ctx, q, newAd.UserID, sqlite.FormatTime(createdAt.Add(-ad.CreationHistory)))
+		return err
+	})
+	if err != nil {
+		if sqlite.IsForeignKeyViolation(err) {
+			return ad.Ad{}, apperror.Wrap(ad.ErrUnknownUser, err)
+		}
+		return ad.Ad{}, err
+	}
+	return newAd, nil
+}
+
+func (r repository) Update(ctx context.Context, newAd ad.Ad, id int) (ad.Ad, error) {
+	q := `
+		update ads
+		set
+			title = ?,
+			description = ?,
+			price = ?,
+			version = version + 1,
+			updated_at = ?
+		where id = ? and (? = 0 or version = ?)
+		returning id, title, description, price, user_id, version, created_at, updated_at`
+	err := r.conn(ctx).QueryRowContext(ctx, q, newAd.Title, newAd.Description, newAd.Price, sqlite.FormatTime(time.Now()), id, newAd.Version, newAd.Version).
+		Scan(&newAd.ID, &newAd.Title, &newAd.Description, &newAd.Price, &newAd.UserID, &newAd.Version, sqlite.ScanTime(&newAd.CreatedAt), sqlite.ScanTime(&newAd.UpdatedAt))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ad.Ad{}, r.missing(ctx, id, newAd.Version)
+		}
+		return ad.Ad{}, err
+	}
+	return newAd, nil
+}
+
+// missing explains why a conditional update matched no row.
+func (r repository) missing(ctx context.Context, id, version int) error {
+	if version == 0 {
+		return ad.ErrNotFound
+	}
+	var exists bool
+	err := r.conn(ctx).QueryRowContext(ctx, `select exists (select 1 from ads where id = ?)`, id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ad.ErrNotFound
+	}
+	return ad.ErrVersionMismatch
+}
+
+func (r repository) Delete(ctx context.Context, id int) error {
+	q := `
+		delete from ads
+		where id = ?`
+	res, err := r.conn(ctx).ExecContext(ctx, q, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ad.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r repository) CountByUser(ctx context.Context, userID int, since time.Time) (ad.Usage, error) {
+	// julianday compares the instants, whatever their fractional digits.
+	q := `
+		select
+			(select count(*) from ads where user_id = ?),
+			(select count(*) from ad_creations where user_id = ? and julianday(created_at) >= julianday(?))`
+	var usage ad.Usage
+	err := r.conn(ctx).QueryRowContext(ctx, q, userID, userID, sqlite.FormatTime(since)).Scan(&usage.Active, &usage.Created)
+	return usage, err
+}
+
+func NewRepository(db *sql.DB) ad.Repository {
+	return &repository{db: db, tx: sqlite.NewTransactor(db)}
+}
+
+func (r repository) conn(ctx context.Context) sqlite.DBTX {
+	return sqlite.Conn(ctx, r.db)
+}
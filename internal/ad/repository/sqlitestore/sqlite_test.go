@@ -0,0 +1,56 @@
+package sqlitestore_test
+
+import (
+	"bulletin-board/internal/ad"
+	"bulletin-board/internal/ad/repository/repotest"
+	"bulletin-board/internal/ad/repository/sqlitestore"
+	"bulletin-board/internal/migrations"
+	"bulletin-board/pkg/sqlite"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestRepository(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) (ad.Repository, int) {
+		ctx := context.Background()
+		db, err := sqlite.NewClient(ctx, filepath.Join(t.TempDir(), "test.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = db.Close() })
+
+		if err := migrations.Up(ctx, migrations.NewSQLiteTarget(db), migrations.SQLite); err != nil {
+			t.Fatal(err)
+		}
+
+		var userID int
+		err = db.QueryRowContext(ctx, `
+			insert into users (name, email, password, birthday, contact)
+			values ('Owner', 'owner@example.com', 'hash', '1990-01-01', '')
+			returning id`).Scan(&userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sqlitestore.NewRepository(db), userID
+	})
+}
+
+func TestCreateUnknownUser(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlite.NewClient(ctx, filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	if err := migrations.Up(ctx, migrations.NewSQLiteTarget(db), migrations.SQLite); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = sqlitestore.NewRepository(db).Create(ctx, ad.Ad{Title: "orphan", UserID: 42})
+	if !errors.Is(err, ad.ErrUnknownUser) {
+		t.Fatalf("Create error = %v, want ad.ErrUnknownUser", err)
+	}
+}
@@ -0,0 +1,70 @@
+package traced
+
+import (
+	"bulletin-board/internal/ad"
+	"bulletin-board/pkg/tracing"
+	"context"
+	"go.opentelemetry.io/otel/attribute"
+	"time"
+)
+
+type repository struct {
+	next ad.Repository
+}
+
+func (r repository) GetAll(ctx context.Context) (ads []ad.Ad, err error) {
+	ctx, span := tracing.Start(ctx, "ad.Repository.GetAll")
+	defer func() { tracing.End(span, err) }()
+
+	ads, err = r.next.GetAll(ctx)
+	span.SetAttributes(attribute.Int("ads.count", len(ads)))
+	return ads, err
+}
+
+func (r repository) GetByID(ctx context.Context, ID int) (_ ad.Ad, err error) {
+	ctx, span := tracing.Start(ctx, "ad.Repository.GetByID", attribute.Int("ad.id", ID))
+	defer func() { tracing.End(span, err) }()
+
+	return r.next.GetByID(ctx, ID)
+}
+
+func (r repository) GetByUserIDs(ctx context.Context, userIDs []int) (ads []ad.Ad, err error) {
+	ctx, span := tracing.Start(ctx, "ad.Repository.GetByUserIDs", attribute.IntSlice("user.ids", userIDs))
+	defer func() { tracing.End(span, err) }()
+
+	ads, err = r.next.GetByUserIDs(ctx, userIDs)
+	span.SetAttributes(attribute.Int("ads.count", len(ads)))
+	return ads, err
+}
+
+func (r repository) Create(ctx context.Context, newAd ad.Ad) (_ ad.Ad, err error) {
+	ctx, span := tracing.Start(ctx, "ad.Repository.Create", attribute.Int("user.id", newAd.UserID))
+	defer func() { tracing.End(span, err) }()
+
+	return r.next.Create(ctx, newAd)
+}
+
+func (r repository) Update(ctx context.Context, newAd ad.Ad, id int) (_ ad.Ad, err error) {
+	ctx, span := tracing.Start(ctx, "ad.Repository.Update", attribute.Int("ad.id", id))
+	defer func() { tracing.End(span, err) }()
+
+	return r.next.Update(ctx, newAd, id)
+}
+
+func (r repository) Delete(ctx context.Context, id int) (err error) {
+	ctx, span := tracing.Start(ctx, "ad.Repository.Delete", attribute.Int("ad.id", id))
+	defer func() { tracing.End(span, err) }()
+
+	return r.next.Delete(ctx, id)
+}
+
+func (r repository) CountByUser(ctx context.Context, userID int, since time.Time) (_ ad.Usage, err error) {
+	ctx, span := tracing.Start(ctx, "ad.Repository.CountByUser", attribute.Int("user.id", userID))
+	defer func() { tracing.End(span, err) }()
+
+	return r.next.CountByUser(ctx, userID, since)
+}
+
+func NewRepository(next ad.Repository) ad.Repository {
+	return repository{next: next}
+}
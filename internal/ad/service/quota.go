@@ -0,0 +1,110 @@
+package service
+
+import (
+	"bulletin-board/internal/ad"
+	"bulletin-board/internal/ad/dto"
+	"bulletin-board/internal/user"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// quotaWindow is the period the daily quota counts created ads over.
+const quotaWindow = 24 * time.Hour
+
+// Quota limits how many ads a user may have at once and how many they
+// may create per day. Zero means no limit.
+type Quota struct {
+	Active int
+	Daily  int
+}
+
+// Quotas holds the quota of each user tier. Users of tiers without an
+// entry get the user.TierBasic quota.
+type Quotas map[string]Quota
+
+func (q Quotas) For(tier string) Quota {
+	if quota, ok := q[tier]; ok {
+		return quota
+	}
+	return q[user.TierBasic]
+}
+
+// ParseQuota reads quotas written as "active/daily", such as "10/5".
+// "0" in either place lifts that limit.
+func ParseQuota(s string) (Quota, error) {
+	active, daily, ok := strings.Cut(s, "/")
+	a, err := strconv.Atoi(active)
+	if !ok || err != nil || a < 0 {
+		return Quota{}, fmt.Errorf("invalid quota %q", s)
+	}
+	d, err := strconv.Atoi(daily)
+	if err != nil || d < 0 {
+		return Quota{}, fmt.Errorf("invalid quota %q", s)
+	}
+	return Quota{Active: a, Daily: d}, nil
+}
+
+// Quota reports the user's tier and how much of its quota they use. Only
+// the user themselves may see it.
+func (s *Service) Quota(ctx context.Context, userID int) (dto.ResponseQuota, error) {
+	if userID <= 0 {
+		return dto.ResponseQuota{}, user.ErrInvalidUserId
+	}
+
+	authId, ok := ctx.Value("user_id").(int)
+	if !ok {
+		return dto.ResponseQuota{}, errInvalidAuth
+	}
+	if authId != userID {
+		return dto.ResponseQuota{}, ad.ErrForbidden
+	}
+
+	usr, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return dto.ResponseQuota{}, err
+	}
+	usage, err := s.repository.CountByUser(ctx, userID, time.Now().Add(-quotaWindow))
+	if err != nil {
+		return dto.ResponseQuota{}, err
+	}
+
+	quota := s.quotas.For(usr.Tier)
+	return dto.ResponseQuota{
+		Tier:   usr.Tier,
+		Active: dto.QuotaUsage{Used: usage.Active, Limit: quota.Active},
+		Daily:  dto.QuotaUsage{Used: usage.Created, Limit: quota.Daily},
+	}, nil
+}
+
+// checkQuota fails if the user may not create another ad. Run it in the
+// transaction that creates the ad.
+func (s *Service) checkQuota(ctx context.Context, userID int) error {
+	usr, err := s.users.GetByID(ctx, userID)
+	if errors.Is(err, user.ErrUserNotFound) {
+		return ad.ErrUnknownUser
+	}
+	if err != nil {
+		return err
+	}
+
+	quota := s.quotas.For(usr.Tier)
+	if quota.Active == 0 && quota.Daily == 0 {
+		return nil
+	}
+
+	usage, err := s.repository.CountByUser(ctx, userID, time.Now().Add(-quotaWindow))
+	if err != nil {
+		return err
+	}
+	if quota.Active > 0 && usage.Active >= quota.Active {
+		return ad.ErrActiveQuotaExceeded
+	}
+	if quota.Daily > 0 && usage.Created >= quota.Daily {
+		return ad.ErrDailyQuotaExceeded
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package service_test
+
+import (
+	"bulletin-board/internal/ad"
+	"bulletin-board/internal/ad/dto"
+	"bulletin-board/internal/ad/service"
+	"bulletin-board/internal/apperror"
+	"bulletin-board/internal/memstore"
+	"bulletin-board/internal/uow"
+	"bulletin-board/internal/user"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// tieredUsers reports every user as a member of tier.
+type tieredUsers struct {
+	user.Repository
+	tier string
+}
+
+func (r tieredUsers) GetByID(ctx context.Context, id int) (user.User, error) {
+	usr, err := r.Repository.GetByID(ctx, id)
+	usr.Tier = r.tier
+	return usr, err
+}
+
+func newService(t *testing.T, tier string, quotas service.Quotas) (*service.Service, ad.Repository, int) {
+	t.Helper()
+	db := memstore.New()
+	owner, err := db.UserRepository().Create(context.Background(), user.User{
+		Name:     "Owner",
+		Email:    "owner@example.com",
+		Birthday: time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := tieredUsers{Repository: db.UserRepository(), tier: tier}
+	return service.NewService(db.AdRepository(), users, uow.NoOp(), quotas), db.AdRepository(), owner.ID
+}
+
+func create(svc *service.Service, userID int) error {
+	_, err := svc.Create(context.Background(), dto.RequestAd{Title: "Bike", Price: 100, UserID: userID})
+	return err
+}
+
+func TestActiveQuota(t *testing.T) {
+	svc, ads, owner := newService(t, user.TierBasic, service.Quotas{user.TierBasic: {Active: 2}})
+
+	for i := 0; i < 2; i++ {
+		if err := create(svc, owner); err != nil {
+			t.Fatalf("Create %d: %v", i, err)
+		}
+	}
+	if err := create(svc, owner); !errors.Is(err, ad.ErrActiveQuotaExceeded) {
+		t.Fatalf("Create over the active quota = %v, want ad.ErrActiveQuotaExceeded", err)
+	}
+
+	if err := ads.Delete(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := create(svc, owner); err != nil {
+		t.Fatalf("Create after deleting an ad: %v", err)
+	}
+}
+
+func TestDailyQuota(t *testing.T) {
+	svc, ads, owner := newService(t, user.TierBasic, service.Quotas{user.TierBasic: {Daily: 2}})
+
+	for i := 0; i < 2; i++ {
+		if err := create(svc, owner); err != nil {
+			t.Fatalf("Create %d: %v", i, err)
+		}
+	}
+	// Deleted ads still count against the day's creations.
+	if err := ads.Delete(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := create(svc, owner); !errors.Is(err, ad.ErrDailyQuotaExceeded) {
+		t.Fatalf("Create over the daily quota = %v, want ad.ErrDailyQuotaExceeded", err)
+	}
+}
+
+func TestQuotaTiers(t *testing.T) {
+	quotas := service.Quotas{
+		user.TierBasic:   {Active: 1, Daily: 5},
+		user.TierPremium: {Active: 10, Daily: 50},
+	}
+	tests := map[string]service.Quota{
+		user.TierPremium: {Active: 10, Daily: 50},
+		// Tiers without a quota of their own get the basic one.
+		user.TierVerified: {Active: 1, Daily: 5},
+	}
+	for tier, want := range tests {
+		svc, _, owner := newService(t, tier, quotas)
+		if err := create(svc, owner); err != nil {
+			t.Fatalf("%s: Create: %v", tier, err)
+		}
+
+		ctx := context.WithValue(context.Background(), "user_id", owner)
+		got, err := svc.Quota(ctx, owner)
+		if err != nil {
+			t.Fatalf("%s: Quota: %v", tier, err)
+		}
+		wantQuota := dto.ResponseQuota{
+			Tier:   tier,
+			Active: dto.QuotaUsage{Used: 1, Limit: want.Active},
+			Daily:  dto.QuotaUsage{Used: 1, Limit: want.Daily},
+		}
+		if got != wantQuota {
+			t.Errorf("%s: Quota = %+v, want %+v", tier, got, wantQuota)
+		}
+	}
+}
+
+func TestQuotaOfAnotherUser(t *testing.T) {
+	svc, _, owner := newService(t, user.TierBasic, service.Quotas{user.TierBasic: {Active: 1}})
+
+	ctx := context.WithValue(context.Background(), "user_id", owner+1)
+	if _, err := svc.Quota(ctx, owner); !errors.Is(err, ad.ErrForbidden) {
+		t.Fatalf("Quota of another user = %v, want ad.ErrForbidden", err)
+	}
+	if _, err := svc.Quota(context.Background(), owner); apperror.KindOf(err) != apperror.KindUnauthorized {
+		t.Fatalf("Quota without auth = %v, want an unauthorized error", err)
+	}
+}
+
+func TestParseQuota(t *testing.T) {
+	valid := map[string]service.Quota{
+		"10/5": {Active: 10, Daily: 5},
+		"0/20": {Daily: 20},
+		"0/0":  {},
+	}
+	for s, want := range valid {
+		if got, err := service.ParseQuota(s); err != nil || got != want {
+			t.Errorf("ParseQuota(%q) = %+v, %v, want %+v", s, got, err, want)
+		}
+	}
+
+	for _, s := range []string{"", "10", "10/", "/5", "-1/5", "10/-5", "a/b", "10/5/1"} {
+		if _, err := service.ParseQuota(s); err == nil {
+			t.Errorf("ParseQuota(%q) succeeded, want an error", s)
+		}
+	}
+}
@@ -3,23 +3,24 @@ package service
 import (
 	"bulletin-board/internal/ad"
 	"bulletin-board/internal/ad/dto"
-	"bulletin-board/internal/redisdb"
+	"bulletin-board/internal/apperror"
+	"bulletin-board/internal/uow"
+	"bulletin-board/internal/user"
+	"bulletin-board/internal/validation"
 	"context"
-	"encoding/json"
-	"errors"
-	"fmt"
-	"github.com/redis/go-redis/v9"
-	"log"
-	"time"
 )
 
+var errInvalidAuth = apperror.Unauthorized("invalid auth")
+
 type Service struct {
 	repository ad.Repository
-	rds        redisdb.RedisClient
+	users      user.Repository
+	tx         uow.UnitOfWork
+	quotas     Quotas
 }
 
-func NewService(repository ad.Repository, rds redisdb.RedisClient) *Service {
-	return &Service{repository: repository, rds: rds}
+func NewService(repository ad.Repository, users user.Repository, tx uow.UnitOfWork, quotas Quotas) *Service {
+	return &Service{repository: repository, users: users, tx: tx, quotas: quotas}
 }
 
 func (s *Service) GetAll(ctx context.Context) ([]dto.ResponseAd, error) {
@@ -37,138 +38,150 @@ func (s *Service) GetAll(ctx context.Context) ([]dto.ResponseAd, error) {
 
 func (s *Service) GetByID(ctx context.Context, ID int) (dto.ResponseAd, error) {
 	if ID <= 0 {
-		return dto.ResponseAd{}, errors.New("invalid id")
-	}
-
-	adObj, err := s.getAdFromRedis(ctx, ID)
-	if err != nil {
-		log.Printf("Redis error: %v", err)
-	}
-
-	if adObj.ID != 0 {
-		return dto.ToDto(adObj), nil
+		return dto.ResponseAd{}, ad.ErrInvalidID
 	}
 
 	ad, err := s.repository.GetByID(ctx, ID)
 	if err != nil {
 		return dto.ResponseAd{}, err
 	}
-
-	err = s.addToRedis(ctx, ID, ad, 10*time.Minute)
-	if err != nil {
-		return dto.ResponseAd{}, err
-	}
-
 	return dto.ToDto(ad), nil
 }
 
 func (s *Service) Create(ctx context.Context, requestAd dto.RequestAd) (dto.ResponseAd, error) {
-	ad := dto.ToAd(requestAd)
-	if err := checkValidityAd(ad); err != nil {
+	if err := validation.Struct(requestAd); err != nil {
 		return dto.ResponseAd{}, err
 	}
-	ad, err := s.repository.Create(ctx, ad)
+
+	var created ad.Ad
+	err := s.tx.Do(ctx, func(ctx context.Context) error {
+		if err := s.checkQuota(ctx, requestAd.UserID); err != nil {
+			return err
+		}
+		var err error
+		created, err = s.repository.Create(ctx, dto.ToAd(requestAd))
+		return err
+	})
 	if err != nil {
 		return dto.ResponseAd{}, err
 	}
-	return dto.ToDto(ad), nil
+	return dto.ToDto(created), nil
 }
 
-func (s *Service) Update(ctx context.Context, requestAd dto.RequestAd, id int) (dto.ResponseAd, error) {
+// Update replaces the ad's fields. A non-zero version makes the update
+// conditional on the ad not having changed since that version was read.
+func (s *Service) Update(ctx context.Context, requestAd dto.RequestAd, id, version int) (dto.ResponseAd, error) {
+	if id <= 0 {
+		return dto.ResponseAd{}, ad.ErrInvalidID
+	}
+
 	reqAd := dto.ToAd(requestAd)
 
 	authId, ok := ctx.Value("user_id").(int)
 	if !ok {
-		return dto.ResponseAd{}, errors.New("invalid auth")
+		return dto.ResponseAd{}, errInvalidAuth
 	}
 
-	if !s.checkValidityUser(ctx, authId, id) {
-		return dto.ResponseAd{}, ad.ErrForbidden
-	}
+	reqAd.UserID = authId
+	reqAd.Version = version
 
-	if err := checkValidityAd(reqAd); err != nil {
-		return dto.ResponseAd{}, err
-	}
+	err := s.tx.Do(ctx, func(ctx context.Context) error {
+		if err := s.checkOwnership(ctx, authId, id); err != nil {
+			return err
+		}
 
-	reqAd.UserID = authId
+		if err := validation.Struct(requestAd); err != nil {
+			return err
+		}
 
-	reqAd, err := s.repository.Update(ctx, reqAd, id)
+		updated, err := s.repository.Update(ctx, reqAd, id)
+		if err != nil {
+			return err
+		}
+		reqAd = updated
+		return nil
+	})
 	if err != nil {
 		return dto.ResponseAd{}, err
 	}
 
-	s.deleteFromRedis(ctx, id)
 	return dto.ToDto(reqAd), nil
 }
 
-func (s *Service) Delete(ctx context.Context, id int) error {
+// Patch applies fn to the ad's current title, description and price and
+// saves the result. The write fails with ad.ErrVersionMismatch if the ad
+// changed after it was read or, when version is non-zero, if it is no
+// longer at that version.
+func (s *Service) Patch(ctx context.Context, id, version int, fn func(*dto.PatchAd) error) (dto.ResponseAd, error) {
 	if id <= 0 {
-		return errors.New("invalid id")
+		return dto.ResponseAd{}, ad.ErrInvalidID
 	}
 
 	authId, ok := ctx.Value("user_id").(int)
-
 	if !ok {
-		return errors.New("invalid auth")
+		return dto.ResponseAd{}, errInvalidAuth
 	}
 
-	if !s.checkValidityUser(ctx, authId, id) {
-		return ad.ErrForbidden
-	}
+	var patched ad.Ad
+	err := s.tx.Do(ctx, func(ctx context.Context) error {
+		current, err := s.repository.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if current.UserID != authId {
+			return ad.ErrForbidden
+		}
+		if version != 0 && version != current.Version {
+			return ad.ErrVersionMismatch
+		}
 
-	s.deleteFromRedis(ctx, id)
-	return s.repository.Delete(ctx, id)
-}
+		doc := dto.ToPatch(current)
+		if err := fn(&doc); err != nil {
+			return err
+		}
+		requestAd := dto.RequestAd{Title: doc.Title, Description: doc.Description, Price: doc.Price, UserID: authId}
+		if err := validation.Struct(requestAd); err != nil {
+			return err
+		}
 
-func checkValidityAd(ad ad.Ad) error {
-	if ad.Price < 0 {
-		return errors.New("invalid price")
-	}
-	if ad.Title == "" {
-		return errors.New("invalid title")
+		next := dto.ToAd(requestAd)
+		next.Version = current.Version
+		patched, err = s.repository.Update(ctx, next, id)
+		return err
+	})
+	if err != nil {
+		return dto.ResponseAd{}, err
 	}
 
-	return nil
+	return dto.ToDto(patched), nil
 }
 
-func (s *Service) checkValidityUser(ctx context.Context, authUser, adId int) bool {
-	ad, err := s.repository.GetByID(ctx, adId)
-	if err != nil {
-		return false
+func (s *Service) Delete(ctx context.Context, id int) error {
+	if id <= 0 {
+		return ad.ErrInvalidID
 	}
-	return ad.UserID == authUser
-}
 
-func (s *Service) addToRedis(ctx context.Context, ID int, adObj ad.Ad, tm time.Duration) error {
-	jsonAd, err := json.Marshal(adObj)
-	if err != nil {
-		return err
+	authId, ok := ctx.Value("user_id").(int)
+
+	if !ok {
+		return errInvalidAuth
 	}
 
-	key := fmt.Sprintf("ad:%d", ID)
-	return s.rds.Rds.Set(ctx, key, jsonAd, tm).Err()
+	return s.tx.Do(ctx, func(ctx context.Context) error {
+		if err := s.checkOwnership(ctx, authId, id); err != nil {
+			return err
+		}
+		return s.repository.Delete(ctx, id)
+	})
 }
 
-func (s *Service) getAdFromRedis(ctx context.Context, ID int) (ad.Ad, error) {
-	key := fmt.Sprintf("ad:%d", ID)
-	jsonAd, err := s.rds.Rds.Get(ctx, key).Result()
+func (s *Service) checkOwnership(ctx context.Context, authUser, adId int) error {
+	adObj, err := s.repository.GetByID(ctx, adId)
 	if err != nil {
-		if errors.Is(err, redis.Nil) {
-			return ad.Ad{}, nil
-		}
-		return ad.Ad{}, err
+		return err
 	}
-
-	var adObj ad.Ad
-	err = json.Unmarshal([]byte(jsonAd), &adObj)
-	if err != nil {
-		return ad.Ad{}, err
+	if adObj.UserID != authUser {
+		return ad.ErrForbidden
 	}
-
-	return adObj, nil
-}
-
-func (s *Service) deleteFromRedis(ctx context.Context, ID int) {
-	key := fmt.Sprintf("ad:%d", ID)
-	s.rds.Rds.Del(ctx, key)
+	return nil
 }
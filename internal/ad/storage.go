@@ -1,18 +1,43 @@
 package ad
 
 import (
+	"bulletin-board/internal/apperror"
 	"context"
-	"errors"
+	"time"
 )
 
 type Repository interface {
 	GetAll(context.Context) ([]Ad, error)
 	GetByID(ctx context.Context, ID int) (Ad, error)
+	// GetByUserIDs returns the ads of the given users, ordered by id.
+	GetByUserIDs(ctx context.Context, userIDs []int) ([]Ad, error)
 	Create(ctx context.Context, ad Ad) (Ad, error)
+	// Update replaces the editable fields of the ad. When ad.Version is
+	// set the update only applies to that version, otherwise it fails
+	// with ErrVersionMismatch.
 	Update(ctx context.Context, ad Ad, id int) (Ad, error)
 	Delete(ctx context.Context, id int) error
+	// CountByUser counts the user's ads and the ads they created at or
+	// after since, including ads deleted since then. since must lie
+	// within CreationHistory.
+	CountByUser(ctx context.Context, userID int, since time.Time) (Usage, error)
 }
 
-var ErrNotFound = errors.New("ad not found")
+// CreationHistory is how long stores remember when ads were created,
+// for CountByUser. Older records may be pruned.
+const CreationHistory = 7 * 24 * time.Hour
 
-var ErrInvalidAd = errors.New("invalid ad")
+type Usage struct {
+	Active  int
+	Created int
+}
+
+var ErrNotFound = apperror.NotFound("ad not found")
+
+var ErrInvalidAd = apperror.Validation("invalid ad")
+
+var ErrInvalidID = apperror.Validation("invalid id", apperror.FieldError{Field: "id", Message: "must be a positive integer"})
+
+var ErrVersionMismatch = apperror.PreconditionFailed("ad has been modified since it was read")
+
+var ErrUnknownUser = apperror.Validation("invalid ad", apperror.FieldError{Field: "user_id", Message: "user does not exist"})
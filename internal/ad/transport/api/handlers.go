@@ -4,10 +4,14 @@ import (
 	"bulletin-board/internal/ad"
 	"bulletin-board/internal/ad/dto"
 	"bulletin-board/internal/ad/service"
+	"bulletin-board/internal/apperror"
+	"bulletin-board/internal/httpbody"
+	"bulletin-board/internal/patch"
+	"bulletin-board/internal/user"
+	"bulletin-board/pkg/httpcache"
 	"encoding/json"
-	"errors"
+	"fmt"
 	"github.com/gorilla/mux"
-	"log"
 	"net/http"
 	"strconv"
 )
@@ -22,12 +26,15 @@ func NewHandler(service service.Service) *Handler {
 
 func (h *Handler) GetAll() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
 		ads, err := h.service.GetAll(r.Context())
 		if err != nil {
-			writeJSONError(w, http.StatusInternalServerError, err.Error())
+			apperror.Write(w, r, err)
+			return
+		}
+		if httpcache.NotModified(w, r, listValidators(ads), cacheControl) {
 			return
 		}
+		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		_ = json.NewEncoder(w).Encode(ads)
 	}
@@ -35,24 +42,21 @@ func (h *Handler) GetAll() http.HandlerFunc {
 
 func (h *Handler) GetByID() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		params := mux.Vars(r)
-		id, err := strconv.Atoi(params["id"])
-
+		id, err := parseID(r)
 		if err != nil {
-			writeJSONError(w, http.StatusBadRequest, err.Error())
+			apperror.Write(w, r, err)
 			return
 		}
 
 		oneAd, err := h.service.GetByID(r.Context(), id)
 		if err != nil {
-			if errors.Is(err, ad.ErrNotFound) {
-				writeJSONError(w, http.StatusNotFound, err.Error())
-			} else {
-				writeJSONError(w, http.StatusInternalServerError, err.Error())
-			}
+			apperror.Write(w, r, err)
+			return
+		}
+		if httpcache.NotModified(w, r, validators(oneAd), cacheControl) {
 			return
 		}
+		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		_ = json.NewEncoder(w).Encode(oneAd)
 	}
@@ -60,27 +64,28 @@ func (h *Handler) GetByID() http.HandlerFunc {
 
 func (h *Handler) Create() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
 		var requestAd dto.RequestAd
-		err := json.NewDecoder(r.Body).Decode(&requestAd)
+		err := httpbody.DecodeJSON(r, &requestAd)
 		if err != nil {
-			writeJSONError(w, http.StatusBadRequest, "invalid request body")
+			apperror.Write(w, r, err)
 			return
 		}
 
 		userId, ok := r.Context().Value("user_id").(int)
 		if !ok {
-			writeJSONError(w, http.StatusForbidden, "forbidden")
+			apperror.Write(w, r, ad.ErrForbidden)
 			return
 		}
 		requestAd.UserID = userId
 
 		ad, err := h.service.Create(r.Context(), requestAd)
 		if err != nil {
-			writeJSONError(w, http.StatusInternalServerError, err.Error())
+			apperror.Write(w, r, err)
 			return
 		}
 
+		httpcache.SetValidators(w, validators(ad))
+		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusCreated)
 		_ = json.NewEncoder(w).Encode(ad)
 	}
@@ -88,60 +93,165 @@ func (h *Handler) Create() http.HandlerFunc {
 
 func (h *Handler) Update() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
 		var requestAd dto.RequestAd
 
-		params := mux.Vars(r)
-		id, err := strconv.Atoi(params["id"])
+		id, err := parseID(r)
+		if err != nil {
+			apperror.Write(w, r, err)
+			return
+		}
 
+		if r.Header.Get("If-Match") == "" {
+			apperror.Write(w, r, errIfMatchRequired)
+			return
+		}
+		version, err := ifMatchVersion(r, id)
 		if err != nil {
-			writeJSONError(w, http.StatusBadRequest, err.Error())
+			apperror.Write(w, r, err)
 			return
 		}
 
-		err = json.NewDecoder(r.Body).Decode(&requestAd)
+		if err := httpbody.DecodeJSON(r, &requestAd); err != nil {
+			apperror.Write(w, r, err)
+			return
+		}
 
-		updatedAd, err := h.service.Update(r.Context(), requestAd, id)
+		updatedAd, err := h.service.Update(r.Context(), requestAd, id, version)
 		if err != nil {
-			if errors.Is(err, ad.ErrNotFound) {
-				writeJSONError(w, http.StatusNotFound, err.Error())
-			} else if errors.Is(err, ad.ErrForbidden) {
-				writeJSONError(w, http.StatusForbidden, err.Error())
-			} else {
-				writeJSONError(w, http.StatusInternalServerError, err.Error())
-			}
+			apperror.Write(w, r, err)
 			return
 		}
+		httpcache.SetValidators(w, validators(updatedAd))
+		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		_ = json.NewEncoder(w).Encode(updatedAd)
 	}
 }
 
-func (h *Handler) Delete() http.HandlerFunc {
+// Patch applies a JSON Merge Patch or JSON Patch to the ad. If-Match is
+// optional: without it the patch applies to whatever version is current.
+func (h *Handler) Patch() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r)
+		if err != nil {
+			apperror.Write(w, r, err)
+			return
+		}
+
+		mediaType, err := patch.MediaType(r)
+		if err != nil {
+			apperror.Write(w, r, err)
+			return
+		}
+		version, err := ifMatchVersion(r, id)
+		if err != nil {
+			apperror.Write(w, r, err)
+			return
+		}
+		body, err := httpbody.Read(r)
+		if err != nil {
+			apperror.Write(w, r, err)
+			return
+		}
+
+		patchedAd, err := h.service.Patch(r.Context(), id, version, func(doc *dto.PatchAd) error {
+			return patch.Apply(mediaType, body, doc)
+		})
+		if err != nil {
+			apperror.Write(w, r, err)
+			return
+		}
+		httpcache.SetValidators(w, validators(patchedAd))
 		w.Header().Set("Content-Type", "application/json")
-		params := mux.Vars(r)
-		id, err := strconv.Atoi(params["id"])
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(patchedAd)
+	}
+}
 
+func (h *Handler) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r)
 		if err != nil {
-			writeJSONError(w, http.StatusBadRequest, "invalid id")
+			apperror.Write(w, r, err)
 			return
 		}
 		err = h.service.Delete(r.Context(), id)
 		if err != nil {
-			if errors.Is(err, ad.ErrNotFound) {
-				writeJSONError(w, http.StatusNotFound, err.Error())
-			} else {
-				writeJSONError(w, http.StatusInternalServerError, err.Error())
-			}
+			apperror.Write(w, r, err)
 			return
 		}
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
 
-func writeJSONError(w http.ResponseWriter, status int, message string) {
-	w.WriteHeader(status)
-	_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
-	log.Printf("Status: %d | Message: %s", status, message)
+// Quota shows the caller's ad quota and how much of it they have used.
+func (h *Handler) Quota() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			apperror.Write(w, r, user.ErrInvalidUserId)
+			return
+		}
+
+		quota, err := h.service.Quota(r.Context(), userID)
+		if err != nil {
+			apperror.Write(w, r, err)
+			return
+		}
+		w.Header().Set("Cache-Control", "private, no-store")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(quota)
+	}
+}
+
+// cacheControl lets clients keep ads but makes them revalidate before
+// every use, since any owner can change them at any time.
+const cacheControl = "no-cache"
+
+var errIfMatchRequired = apperror.PreconditionRequired("If-Match header is required")
+
+func parseID(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		return 0, ad.ErrInvalidID
+	}
+	return id, nil
+}
+
+func etag(a dto.ResponseAd) string {
+	return httpcache.ETag(fmt.Sprintf("ad-%d-v%d", a.ID, a.Version))
+}
+
+func validators(a dto.ResponseAd) httpcache.Validators {
+	return httpcache.Validators{ETag: etag(a), LastModified: a.UpdatedAt}
+}
+
+// listValidators only sets a weak ETag: a deleted ad leaves the newest
+// modification time unchanged, so Last-Modified would go stale.
+func listValidators(ads []dto.ResponseAd) httpcache.Validators {
+	parts := make([]string, len(ads))
+	for i, a := range ads {
+		parts[i] = etag(a)
+	}
+	return httpcache.Validators{ETag: httpcache.WeakETag(parts...)}
+}
+
+// ifMatchVersion returns the ad version the If-Match header was taken
+// from, or 0 for "*" or no header. An entity tag of another ad never
+// matches.
+func ifMatchVersion(r *http.Request, id int) (int, error) {
+	tag, ok := httpcache.IfMatch(r)
+	switch {
+	case !ok:
+		return 0, ad.ErrVersionMismatch
+	case tag == "" || tag == "*":
+		return 0, nil
+	}
+
+	var tagID, version int
+	if _, err := fmt.Sscanf(tag, `"ad-%d-v%d"`, &tagID, &version); err != nil || tagID != id || version <= 0 {
+		return 0, ad.ErrVersionMismatch
+	}
+	return version, nil
 }
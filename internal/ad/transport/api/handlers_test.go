@@ -0,0 +1,115 @@
+package api
+
+import (
+	"bulletin-board/internal/ad/dto"
+	"bulletin-board/internal/ad/service"
+	"bulletin-board/internal/memstore"
+	"bulletin-board/internal/uow"
+	userDto "bulletin-board/internal/user/dto"
+	userServ "bulletin-board/internal/user/service"
+	"context"
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testEnv struct {
+	router http.Handler
+	token  string
+	ad     dto.ResponseAd
+}
+
+func newEnv(t *testing.T) testEnv {
+	t.Helper()
+	t.Setenv("SINGING_KEY", userServ.SigningKey)
+	ctx := context.Background()
+	db := memstore.New()
+	users := userServ.NewService(db.UserRepository(), db.AdRepository(), uow.NoOp())
+	ads := service.NewService(db.AdRepository(), db.UserRepository(), uow.NoOp(), nil)
+
+	owner, err := users.Create(ctx, userDto.RequestUser{Name: "Annie", Email: "annie@example.com", Password: "Secret123", Birthday: "1990-01-01"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := users.GenerateToken(ctx, owner.Email, "Secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	created, err := ads.Create(ctx, dto.RequestAd{Title: "Bike", Price: 100, UserID: owner.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := mux.NewRouter()
+	NewHandler(*ads).NewRouter(r)
+	return testEnv{router: r, token: token, ad: created}
+}
+
+func (e testEnv) serve(method, path string, header http.Header, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	for k, v := range header {
+		r.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	e.router.ServeHTTP(w, r)
+	return w
+}
+
+func TestGetByIDNotModified(t *testing.T) {
+	env := newEnv(t)
+	path := fmt.Sprintf("/ads/%d", env.ad.ID)
+
+	w := env.serve(http.MethodGet, path, nil, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET = %d", w.Code)
+	}
+	etag, lastModified := w.Header().Get("ETag"), w.Header().Get("Last-Modified")
+	if etag == "" || lastModified == "" {
+		t.Fatalf("GET headers = %v, want ETag and Last-Modified", w.Header())
+	}
+
+	for name, header := range map[string]http.Header{
+		"If-None-Match":     {"If-None-Match": {etag}},
+		"If-Modified-Since": {"If-Modified-Since": {lastModified}},
+	} {
+		if w := env.serve(http.MethodGet, path, header, ""); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+			t.Errorf("GET with %s = %d %q, want an empty 304", name, w.Code, w.Body)
+		}
+	}
+	if w := env.serve(http.MethodGet, path, http.Header{"If-None-Match": {`"ad-1-v0"`}}, ""); w.Code != http.StatusOK {
+		t.Errorf("GET with another ETag = %d, want 200", w.Code)
+	}
+}
+
+func TestUpdatePreconditions(t *testing.T) {
+	env := newEnv(t)
+	path := fmt.Sprintf("/ads/%d", env.ad.ID)
+	const body = `{"title": "Boat", "price": 200}`
+	header := func(ifMatch string) http.Header {
+		h := http.Header{"Authorization": {"Bearer " + env.token}, "Content-Type": {"application/json"}}
+		if ifMatch != "" {
+			h.Set("If-Match", ifMatch)
+		}
+		return h
+	}
+
+	if w := env.serve(http.MethodPut, path, header(""), body); w.Code != http.StatusPreconditionRequired {
+		t.Fatalf("PUT without If-Match = %d, want 428", w.Code)
+	}
+
+	current := etag(env.ad)
+	w := env.serve(http.MethodPut, path, header(current), body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT with a current If-Match = %d %s", w.Code, w.Body)
+	}
+	if got := w.Header().Get("ETag"); got == "" || got == current {
+		t.Fatalf("ETag after PUT = %q, want a new one", got)
+	}
+
+	if w := env.serve(http.MethodPut, path, header(current), body); w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("PUT with a stale If-Match = %d, want 412", w.Code)
+	}
+}
@@ -16,5 +16,8 @@ func (h Handler) NewRouter(r *mux.Router) {
 
 	secured.HandleFunc("", h.Create()).Methods("POST")
 	secured.HandleFunc("/{id}", h.Update()).Methods("PUT")
+	secured.HandleFunc("/{id}", h.Patch()).Methods("PATCH")
 	secured.HandleFunc("/{id}", h.Delete()).Methods("DELETE")
+
+	r.Handle("/users/{id}/quota", middleware.AuthMiddleware(secretKey)(h.Quota())).Methods("GET")
 }
@@ -0,0 +1,96 @@
+package grpcapi
+
+import (
+	"bulletin-board/internal/ad/dto"
+	"bulletin-board/internal/ad/service"
+	bulletinv1 "bulletin-board/pkg/pb/bulletin/v1"
+	"context"
+	"google.golang.org/grpc"
+)
+
+// SecuredMethods lists the RPCs that need an authenticated user.
+var SecuredMethods = []string{
+	bulletinv1.AdService_CreateAd_FullMethodName,
+	bulletinv1.AdService_UpdateAd_FullMethodName,
+	bulletinv1.AdService_DeleteAd_FullMethodName,
+}
+
+type Server struct {
+	bulletinv1.UnimplementedAdServiceServer
+	service *service.Service
+}
+
+func NewServer(service *service.Service) *Server {
+	return &Server{service: service}
+}
+
+func (s *Server) Register(gs *grpc.Server) {
+	bulletinv1.RegisterAdServiceServer(gs, s)
+}
+
+func (s *Server) ListAds(ctx context.Context, _ *bulletinv1.ListAdsRequest) (*bulletinv1.ListAdsResponse, error) {
+	ads, err := s.service.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &bulletinv1.ListAdsResponse{Ads: ToProtoList(ads)}, nil
+}
+
+func (s *Server) GetAd(ctx context.Context, req *bulletinv1.GetAdRequest) (*bulletinv1.GetAdResponse, error) {
+	ad, err := s.service.GetByID(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, err
+	}
+	return &bulletinv1.GetAdResponse{Ad: ToProto(ad)}, nil
+}
+
+func (s *Server) CreateAd(ctx context.Context, req *bulletinv1.CreateAdRequest) (*bulletinv1.CreateAdResponse, error) {
+	userId, _ := ctx.Value("user_id").(int)
+	ad, err := s.service.Create(ctx, dto.RequestAd{
+		Title:       req.GetTitle(),
+		Description: req.GetDescription(),
+		Price:       int(req.GetPrice()),
+		UserID:      userId,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &bulletinv1.CreateAdResponse{Ad: ToProto(ad)}, nil
+}
+
+func (s *Server) UpdateAd(ctx context.Context, req *bulletinv1.UpdateAdRequest) (*bulletinv1.UpdateAdResponse, error) {
+	ad, err := s.service.Update(ctx, dto.RequestAd{
+		Title:       req.GetTitle(),
+		Description: req.GetDescription(),
+		Price:       int(req.GetPrice()),
+	}, int(req.GetId()), 0)
+	if err != nil {
+		return nil, err
+	}
+	return &bulletinv1.UpdateAdResponse{Ad: ToProto(ad)}, nil
+}
+
+func (s *Server) DeleteAd(ctx context.Context, req *bulletinv1.DeleteAdRequest) (*bulletinv1.DeleteAdResponse, error) {
+	if err := s.service.Delete(ctx, int(req.GetId())); err != nil {
+		return nil, err
+	}
+	return &bulletinv1.DeleteAdResponse{}, nil
+}
+
+func ToProto(ad dto.ResponseAd) *bulletinv1.Ad {
+	return &bulletinv1.Ad{
+		Id:          int64(ad.ID),
+		Title:       ad.Title,
+		Description: ad.Description,
+		Price:       int64(ad.Price),
+		UserId:      int64(ad.UserID),
+	}
+}
+
+func ToProtoList(ads []dto.ResponseAd) []*bulletinv1.Ad {
+	res := make([]*bulletinv1.Ad, 0, len(ads))
+	for _, ad := range ads {
+		res = append(res, ToProto(ad))
+	}
+	return res
+}
@@ -0,0 +1,130 @@
+package grpcapi
+
+import (
+	"bulletin-board/internal/ad/service"
+	"bulletin-board/internal/grpctest"
+	"bulletin-board/internal/memstore"
+	"bulletin-board/internal/uow"
+	"bulletin-board/internal/user/dto"
+	userServ "bulletin-board/internal/user/service"
+	bulletinv1 "bulletin-board/pkg/pb/bulletin/v1"
+	"context"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+type testEnv struct {
+	client bulletinv1.AdServiceClient
+	users  *userServ.Service
+}
+
+func newEnv(t *testing.T) testEnv {
+	t.Helper()
+	db := memstore.New()
+	ads := service.NewService(db.AdRepository(), db.UserRepository(), uow.NoOp(), nil)
+
+	conn := grpctest.Dial(t, SecuredMethods, NewServer(ads).Register)
+	return testEnv{
+		client: bulletinv1.NewAdServiceClient(conn),
+		users:  userServ.NewService(db.UserRepository(), db.AdRepository(), uow.NoOp()),
+	}
+}
+
+// signUp registers a user and returns a context carrying its token.
+func (e testEnv) signUp(t *testing.T, email string) context.Context {
+	t.Helper()
+	ctx := context.Background()
+	_, err := e.users.Create(ctx, dto.RequestUser{Name: "Annie", Email: email, Password: "Secret123", Birthday: "1990-01-01"})
+	if err != nil {
+		t.Fatalf("Create user: %v", err)
+	}
+	token, err := e.users.GenerateToken(ctx, email, "Secret123")
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
+
+func TestSecuredMethods(t *testing.T) {
+	env := newEnv(t)
+	annie := env.signUp(t, "annie@example.com")
+	created, err := env.client.CreateAd(annie, &bulletinv1.CreateAdRequest{Title: "Bike", Price: 100})
+	if err != nil {
+		t.Fatalf("CreateAd: %v", err)
+	}
+	id := created.GetAd().GetId()
+	ctx := context.Background()
+
+	if _, err := env.client.CreateAd(ctx, &bulletinv1.CreateAdRequest{Title: "Bike", Price: 100}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("CreateAd without a token = %v, want Unauthenticated", err)
+	}
+	if _, err := env.client.UpdateAd(ctx, &bulletinv1.UpdateAdRequest{Id: id, Title: "Car"}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("UpdateAd without a token = %v, want Unauthenticated", err)
+	}
+	if _, err := env.client.DeleteAd(ctx, &bulletinv1.DeleteAdRequest{Id: id}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("DeleteAd without a token = %v, want Unauthenticated", err)
+	}
+	if got, err := env.client.GetAd(ctx, &bulletinv1.GetAdRequest{Id: id}); err != nil || got.GetAd().GetTitle() != "Bike" {
+		t.Errorf("GetAd without a token = %v, %v", got, err)
+	}
+}
+
+func TestForbidden(t *testing.T) {
+	env := newEnv(t)
+	annie := env.signUp(t, "annie@example.com")
+	bob := env.signUp(t, "bob@example.com")
+	created, err := env.client.CreateAd(annie, &bulletinv1.CreateAdRequest{Title: "Bike", Price: 100})
+	if err != nil {
+		t.Fatalf("CreateAd: %v", err)
+	}
+	id := created.GetAd().GetId()
+
+	if _, err := env.client.UpdateAd(bob, &bulletinv1.UpdateAdRequest{Id: id, Title: "Stolen"}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("UpdateAd of another user's ad = %v, want PermissionDenied", err)
+	}
+	if _, err := env.client.DeleteAd(bob, &bulletinv1.DeleteAdRequest{Id: id}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("DeleteAd of another user's ad = %v, want PermissionDenied", err)
+	}
+	if got, err := env.client.GetAd(context.Background(), &bulletinv1.GetAdRequest{Id: id}); err != nil || got.GetAd().GetTitle() != "Bike" {
+		t.Fatalf("forbidden calls changed the ad: %v, %v", got, err)
+	}
+
+	if _, err := env.client.UpdateAd(annie, &bulletinv1.UpdateAdRequest{Id: id, Title: "Red bike", Price: 90}); err != nil {
+		t.Errorf("UpdateAd of own ad: %v", err)
+	}
+	if _, err := env.client.DeleteAd(annie, &bulletinv1.DeleteAdRequest{Id: id}); err != nil {
+		t.Errorf("DeleteAd of own ad: %v", err)
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	env := newEnv(t)
+	annie := env.signUp(t, "annie@example.com")
+
+	_, err := env.client.CreateAd(annie, &bulletinv1.CreateAdRequest{Title: "B", Price: -1})
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("CreateAd with invalid fields = %v, want InvalidArgument", err)
+	}
+	violations := map[string]bool{}
+	for _, d := range st.Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			for _, v := range br.GetFieldViolations() {
+				violations[v.GetField()] = v.GetDescription() != ""
+			}
+		}
+	}
+	if !violations["title"] || !violations["price"] || len(violations) != 2 {
+		t.Errorf("field violations = %v, want title and price", violations)
+	}
+
+	if _, err := env.client.GetAd(context.Background(), &bulletinv1.GetAdRequest{Id: 424242}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetAd of a missing ad = %v, want NotFound", err)
+	}
+	if _, err := env.client.UpdateAd(annie, &bulletinv1.UpdateAdRequest{Id: 424242, Title: "Car"}); status.Code(err) != codes.NotFound {
+		t.Errorf("UpdateAd of a missing ad = %v, want NotFound", err)
+	}
+}
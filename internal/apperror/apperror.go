@@ -0,0 +1,147 @@
+package apperror
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindBadRequest
+	KindValidation
+	KindNotFound
+	KindConflict
+	KindForbidden
+	KindUnauthorized
+	KindPreconditionFailed
+	KindPreconditionRequired
+	KindUnsupportedMediaType
+	KindUnprocessable
+	KindTooManyRequests
+	KindTooLarge
+	KindTimeout
+)
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type Error struct {
+	Kind    Kind
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if len(e.Fields) == 0 {
+		return e.Message
+	}
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, f.Field+": "+f.Message)
+	}
+	return e.Message + ": " + strings.Join(parts, "; ")
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func BadRequest(message string) *Error {
+	return &Error{Kind: KindBadRequest, Message: message}
+}
+
+func Validation(message string, fields ...FieldError) *Error {
+	return &Error{Kind: KindValidation, Message: message, Fields: fields}
+}
+
+func NotFound(message string) *Error {
+	return &Error{Kind: KindNotFound, Message: message}
+}
+
+func Conflict(message string) *Error {
+	return &Error{Kind: KindConflict, Message: message}
+}
+
+func Forbidden(message string) *Error {
+	return &Error{Kind: KindForbidden, Message: message}
+}
+
+func Unauthorized(message string) *Error {
+	return &Error{Kind: KindUnauthorized, Message: message}
+}
+
+func PreconditionFailed(message string) *Error {
+	return &Error{Kind: KindPreconditionFailed, Message: message}
+}
+
+func PreconditionRequired(message string) *Error {
+	return &Error{Kind: KindPreconditionRequired, Message: message}
+}
+
+func UnsupportedMediaType(message string) *Error {
+	return &Error{Kind: KindUnsupportedMediaType, Message: message}
+}
+
+func Unprocessable(message string) *Error {
+	return &Error{Kind: KindUnprocessable, Message: message}
+}
+
+func TooManyRequests(message string) *Error {
+	return &Error{Kind: KindTooManyRequests, Message: message}
+}
+
+func TooLarge(message string) *Error {
+	return &Error{Kind: KindTooLarge, Message: message}
+}
+
+func Timeout(message string) *Error {
+	return &Error{Kind: KindTimeout, Message: message}
+}
+
+var errTimedOut = Timeout("request timed out")
+
+// Wrap returns a copy of target that also carries cause, so that
+// errors.Is matches both the sentinel and the underlying error.
+func Wrap(target *Error, cause error) *Error {
+	return &Error{Kind: target.Kind, Message: target.Message, Fields: target.Fields, Err: &wrapped{target: target, cause: cause}}
+}
+
+type wrapped struct {
+	target *Error
+	cause  error
+}
+
+func (w *wrapped) Error() string {
+	return w.cause.Error()
+}
+
+func (w *wrapped) Unwrap() []error {
+	return []error{w.target, w.cause}
+}
+
+// As finds the first *Error in err's chain. Errors caused by a context
+// deadline running out are reported as timeouts.
+func As(err error) (*Error, bool) {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr, true
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return errTimedOut, true
+	}
+	return nil, false
+}
+
+// KindOf reports the kind of the first *Error in err's chain.
+func KindOf(err error) Kind {
+	if appErr, ok := As(err); ok {
+		return appErr.Kind
+	}
+	return KindInternal
+}
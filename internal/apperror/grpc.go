@@ -0,0 +1,50 @@
+package apperror
+
+import (
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var grpcCodes = map[Kind]codes.Code{
+	KindInternal:             codes.Internal,
+	KindBadRequest:           codes.InvalidArgument,
+	KindValidation:           codes.InvalidArgument,
+	KindNotFound:             codes.NotFound,
+	KindConflict:             codes.AlreadyExists,
+	KindForbidden:            codes.PermissionDenied,
+	KindUnauthorized:         codes.Unauthenticated,
+	KindPreconditionFailed:   codes.FailedPrecondition,
+	KindPreconditionRequired: codes.FailedPrecondition,
+	KindUnsupportedMediaType: codes.InvalidArgument,
+	KindUnprocessable:        codes.InvalidArgument,
+	KindTooManyRequests:      codes.ResourceExhausted,
+	KindTooLarge:             codes.InvalidArgument,
+	KindTimeout:              codes.DeadlineExceeded,
+}
+
+// GRPCStatus converts err to a gRPC status error. Like Write, it hides
+// the text of errors that are not an *Error.
+func GRPCStatus(err error) error {
+	appErr, ok := As(err)
+	if !ok {
+		return status.Error(codes.Internal, "internal server error")
+	}
+
+	st := status.New(grpcCodes[appErr.Kind], appErr.Message)
+	if len(appErr.Fields) == 0 {
+		return st.Err()
+	}
+
+	details := &errdetails.BadRequest{}
+	for _, f := range appErr.Fields {
+		details.FieldViolations = append(details.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       f.Field,
+			Description: f.Message,
+		})
+	}
+	if withDetails, err := st.WithDetails(details); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
@@ -0,0 +1,80 @@
+package apperror
+
+import (
+	"bulletin-board/pkg/logger"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details object.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+var kinds = map[Kind]struct {
+	status  int
+	problem string
+}{
+	KindInternal:             {http.StatusInternalServerError, "internal"},
+	KindBadRequest:           {http.StatusBadRequest, "bad-request"},
+	KindValidation:           {http.StatusBadRequest, "validation"},
+	KindNotFound:             {http.StatusNotFound, "not-found"},
+	KindConflict:             {http.StatusConflict, "conflict"},
+	KindForbidden:            {http.StatusForbidden, "forbidden"},
+	KindUnauthorized:         {http.StatusUnauthorized, "unauthorized"},
+	KindPreconditionFailed:   {http.StatusPreconditionFailed, "precondition-failed"},
+	KindPreconditionRequired: {http.StatusPreconditionRequired, "precondition-required"},
+	KindUnsupportedMediaType: {http.StatusUnsupportedMediaType, "unsupported-media-type"},
+	KindUnprocessable:        {http.StatusUnprocessableEntity, "unprocessable"},
+	KindTooManyRequests:      {http.StatusTooManyRequests, "too-many-requests"},
+	KindTooLarge:             {http.StatusRequestEntityTooLarge, "too-large"},
+	KindTimeout:              {http.StatusServiceUnavailable, "timeout"},
+}
+
+func Status(err error) int {
+	return kinds[KindOf(err)].status
+}
+
+func ToProblem(r *http.Request, err error) Problem {
+	kind := KindOf(err)
+	status := kinds[kind].status
+
+	p := Problem{
+		Type:      "/problems/" + kinds[kind].problem,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Instance:  r.URL.Path,
+		RequestID: logger.RequestID(r.Context()),
+	}
+
+	if appErr, ok := As(err); ok {
+		p.Detail = appErr.Message
+		p.Errors = appErr.Fields
+	}
+	return p
+}
+
+// Write renders err as application/problem+json. Errors that are not
+// an *Error are reported as 500 without exposing their text.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	p := ToProblem(r, err)
+
+	if p.Status >= http.StatusInternalServerError {
+		slog.ErrorContext(r.Context(), "request failed", slog.Int("status", p.Status), slog.Any("error", err))
+	} else {
+		slog.WarnContext(r.Context(), "request failed", slog.Int("status", p.Status), slog.Any("error", err))
+	}
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
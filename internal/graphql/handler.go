@@ -0,0 +1,100 @@
+package graphql
+
+import (
+	adServ "bulletin-board/internal/ad/service"
+	"bulletin-board/internal/apperror"
+	"bulletin-board/internal/middleware"
+	userServ "bulletin-board/internal/user/service"
+	_ "embed"
+	"github.com/gorilla/mux"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+	"log/slog"
+	"os"
+)
+
+//go:embed schema.graphql
+var schema string
+
+const (
+	// maxDepth stops queries that nest ads and sellers without end.
+	maxDepth = 8
+	// maxParallelism bounds the resolvers running at once per request,
+	// and so also how many lookups a dataloader batch collects.
+	maxParallelism = 20
+)
+
+type Handler struct {
+	schema  *graphql.Schema
+	users   *userServ.Service
+	limiter middleware.RateLimiter
+}
+
+// NewHandler serves the GraphQL API. limits maps mutation names to the
+// rate limit charged on every call, since one document may call a
+// mutation many times under aliases; limiter may be nil.
+func NewHandler(ads *adServ.Service, users *userServ.Service, limiter middleware.RateLimiter, limits map[string]middleware.RateLimitRule) *Handler {
+	return &Handler{
+		schema: graphql.MustParseSchema(schema, &resolver{ads: ads, users: users, limiter: limiter, limits: limits},
+			graphql.MaxDepth(maxDepth),
+			graphql.MaxParallelism(maxParallelism),
+		),
+		users:   users,
+		limiter: limiter,
+	}
+}
+
+func (h *Handler) NewRouter(r *mux.Router) {
+	secretKey := os.Getenv("SINGING_KEY")
+	handler := middleware.OptionalAuth(secretKey)(withLoaders(h.users, &relay.Handler{Schema: h.schema}))
+	if h.limiter != nil {
+		handler = middleware.RateLimitCaller(secretKey)(handler)
+	}
+	r.Handle("/graphql", handler).Methods("POST")
+}
+
+// resolverError exposes the problem type and field errors of an
+// apperror to GraphQL clients under "extensions".
+type resolverError struct {
+	message    string
+	extensions map[string]any
+}
+
+func (e resolverError) Error() string {
+	return e.message
+}
+
+func (e resolverError) Extensions() map[string]any {
+	return e.extensions
+}
+
+func toError(err error) error {
+	appErr, ok := apperror.As(err)
+	if !ok {
+		slog.Error("graphql resolver failed", slog.Any("error", err))
+		return resolverError{message: "internal server error", extensions: map[string]any{"code": "INTERNAL"}}
+	}
+
+	ext := map[string]any{"code": codes[appErr.Kind]}
+	if len(appErr.Fields) > 0 {
+		ext["errors"] = appErr.Fields
+	}
+	return resolverError{message: appErr.Message, extensions: ext}
+}
+
+var codes = map[apperror.Kind]string{
+	apperror.KindInternal:             "INTERNAL",
+	apperror.KindBadRequest:           "BAD_REQUEST",
+	apperror.KindValidation:           "VALIDATION",
+	apperror.KindNotFound:             "NOT_FOUND",
+	apperror.KindConflict:             "CONFLICT",
+	apperror.KindForbidden:            "FORBIDDEN",
+	apperror.KindUnauthorized:         "UNAUTHENTICATED",
+	apperror.KindPreconditionFailed:   "PRECONDITION_FAILED",
+	apperror.KindPreconditionRequired: "PRECONDITION_REQUIRED",
+	apperror.KindUnsupportedMediaType: "UNSUPPORTED_MEDIA_TYPE",
+	apperror.KindUnprocessable:        "UNPROCESSABLE",
+	apperror.KindTooManyRequests:      "TOO_MANY_REQUESTS",
+	apperror.KindTooLarge:             "TOO_LARGE",
+	apperror.KindTimeout:              "TIMEOUT",
+}
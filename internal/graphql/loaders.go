@@ -0,0 +1,71 @@
+package graphql
+
+import (
+	adDto "bulletin-board/internal/ad/dto"
+	"bulletin-board/internal/user"
+	"bulletin-board/internal/user/dto"
+	userServ "bulletin-board/internal/user/service"
+	"context"
+	"github.com/graph-gophers/dataloader/v7"
+	"net/http"
+	"time"
+)
+
+type loadersKey struct{}
+
+// loaders batch the lookups made while resolving one request, so a list
+// of ads with their sellers costs one users query instead of one per ad.
+type loaders struct {
+	users   *dataloader.Loader[int, dto.ResponseUser]
+	userAds *dataloader.Loader[int, []adDto.ResponseAd]
+}
+
+func newLoaders(users *userServ.Service) *loaders {
+	wait := dataloader.WithWait[int, dto.ResponseUser](2 * time.Millisecond)
+	adsWait := dataloader.WithWait[int, []adDto.ResponseAd](2 * time.Millisecond)
+
+	return &loaders{
+		users: dataloader.NewBatchedLoader(func(ctx context.Context, ids []int) []*dataloader.Result[dto.ResponseUser] {
+			found, err := users.GetByIDs(ctx, ids)
+			res := make([]*dataloader.Result[dto.ResponseUser], len(ids))
+			for i, id := range ids {
+				switch usr, ok := found[id]; {
+				case err != nil:
+					res[i] = &dataloader.Result[dto.ResponseUser]{Error: err}
+				case !ok:
+					res[i] = &dataloader.Result[dto.ResponseUser]{Error: user.ErrUserNotFound}
+				default:
+					res[i] = &dataloader.Result[dto.ResponseUser]{Data: usr}
+				}
+			}
+			return res
+		}, wait),
+		userAds: dataloader.NewBatchedLoader(func(ctx context.Context, ids []int) []*dataloader.Result[[]adDto.ResponseAd] {
+			found, err := users.GetAdsByUserIDs(ctx, ids)
+			res := make([]*dataloader.Result[[]adDto.ResponseAd], len(ids))
+			for i, id := range ids {
+				if err != nil {
+					res[i] = &dataloader.Result[[]adDto.ResponseAd]{Error: err}
+					continue
+				}
+				ads := found[id]
+				if ads == nil {
+					ads = []adDto.ResponseAd{}
+				}
+				res[i] = &dataloader.Result[[]adDto.ResponseAd]{Data: ads}
+			}
+			return res
+		}, adsWait),
+	}
+}
+
+func withLoaders(users *userServ.Service, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), loadersKey{}, newLoaders(users))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
@@ -0,0 +1,329 @@
+package graphql
+
+import (
+	adDto "bulletin-board/internal/ad/dto"
+	adServ "bulletin-board/internal/ad/service"
+	"bulletin-board/internal/apperror"
+	"bulletin-board/internal/middleware"
+	"bulletin-board/internal/user/dto"
+	userServ "bulletin-board/internal/user/service"
+	"context"
+	"github.com/graph-gophers/graphql-go"
+	"math"
+	"strconv"
+)
+
+var (
+	errUnauthorized = apperror.Unauthorized("authentication required")
+	errForbidden    = apperror.Forbidden("forbidden")
+	errInvalidID    = apperror.Validation("invalid id", apperror.FieldError{Field: "id", Message: "must be a positive integer"})
+	errInvalidPrice = apperror.Validation("invalid price", apperror.FieldError{Field: "price", Message: "must be an integer"})
+)
+
+// maxPrice is the largest integer a float64 holds exactly.
+const maxPrice = 1 << 53
+
+type resolver struct {
+	ads     *adServ.Service
+	users   *userServ.Service
+	limiter middleware.RateLimiter
+	limits  map[string]middleware.RateLimitRule
+}
+
+type adInput struct {
+	Title       string
+	Description string
+	Price       float64
+}
+
+func (in adInput) toRequest() (adDto.RequestAd, error) {
+	if in.Price != math.Trunc(in.Price) || math.Abs(in.Price) > maxPrice {
+		return adDto.RequestAd{}, errInvalidPrice
+	}
+	return adDto.RequestAd{
+		Title:       in.Title,
+		Description: in.Description,
+		Price:       int(in.Price),
+	}, nil
+}
+
+type createUserInput struct {
+	Name     string
+	Email    string
+	Password string
+	Birthday string
+	Contact  string
+}
+
+type updateUserInput struct {
+	Name     string
+	Birthday string
+	Contact  string
+}
+
+func (r *resolver) Ads(ctx context.Context) ([]*adResolver, error) {
+	ads, err := r.ads.GetAll(ctx)
+	if err != nil {
+		return nil, toError(err)
+	}
+	return r.adResolvers(ads), nil
+}
+
+func (r *resolver) Ad(ctx context.Context, args struct{ ID graphql.ID }) (*adResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, toError(err)
+	}
+	ad, err := r.ads.GetByID(ctx, id)
+	if err != nil {
+		if apperror.KindOf(err) == apperror.KindNotFound {
+			return nil, nil
+		}
+		return nil, toError(err)
+	}
+	return &adResolver{ad: ad, r: r}, nil
+}
+
+func (r *resolver) Users(ctx context.Context) ([]*userResolver, error) {
+	users, err := r.users.GetAll(ctx)
+	if err != nil {
+		return nil, toError(err)
+	}
+	res := make([]*userResolver, 0, len(users))
+	for _, usr := range users {
+		res = append(res, &userResolver{user: usr, r: r})
+	}
+	return res, nil
+}
+
+func (r *resolver) User(ctx context.Context, args struct{ ID graphql.ID }) (*userResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, toError(err)
+	}
+	usr, err := loadersFrom(ctx).users.Load(ctx, id)()
+	if err != nil {
+		if apperror.KindOf(err) == apperror.KindNotFound {
+			return nil, nil
+		}
+		return nil, toError(err)
+	}
+	return &userResolver{user: usr, r: r}, nil
+}
+
+func (r *resolver) CreateAd(ctx context.Context, args struct{ Input adInput }) (*adResolver, error) {
+	userId, ok := ctx.Value("user_id").(int)
+	if !ok {
+		return nil, toError(errUnauthorized)
+	}
+	req, err := args.Input.toRequest()
+	if err != nil {
+		return nil, toError(err)
+	}
+	req.UserID = userId
+	ad, err := r.ads.Create(ctx, req)
+	if err != nil {
+		return nil, toError(err)
+	}
+	return &adResolver{ad: ad, r: r}, nil
+}
+
+func (r *resolver) UpdateAd(ctx context.Context, args struct {
+	ID    graphql.ID
+	Input adInput
+}) (*adResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, toError(err)
+	}
+	if _, ok := ctx.Value("user_id").(int); !ok {
+		return nil, toError(errUnauthorized)
+	}
+	req, err := args.Input.toRequest()
+	if err != nil {
+		return nil, toError(err)
+	}
+	ad, err := r.ads.Update(ctx, req, id, 0)
+	if err != nil {
+		return nil, toError(err)
+	}
+	return &adResolver{ad: ad, r: r}, nil
+}
+
+func (r *resolver) DeleteAd(ctx context.Context, args struct{ ID graphql.ID }) (bool, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return false, toError(err)
+	}
+	if _, ok := ctx.Value("user_id").(int); !ok {
+		return false, toError(errUnauthorized)
+	}
+	if err := r.ads.Delete(ctx, id); err != nil {
+		return false, toError(err)
+	}
+	return true, nil
+}
+
+func (r *resolver) CreateUser(ctx context.Context, args struct{ Input createUserInput }) (*userResolver, error) {
+	if err := r.rateLimit(ctx, "createUser"); err != nil {
+		return nil, toError(err)
+	}
+	usr, err := r.users.Create(ctx, dto.RequestUser{
+		Name:     args.Input.Name,
+		Email:    args.Input.Email,
+		Password: args.Input.Password,
+		Birthday: args.Input.Birthday,
+		Contact:  args.Input.Contact,
+	})
+	if err != nil {
+		return nil, toError(err)
+	}
+	return &userResolver{user: usr, r: r}, nil
+}
+
+func (r *resolver) UpdateUser(ctx context.Context, args struct {
+	ID    graphql.ID
+	Input updateUserInput
+}) (*userResolver, error) {
+	id, err := r.authorizeUser(ctx, args.ID)
+	if err != nil {
+		return nil, toError(err)
+	}
+	usr, err := r.users.Update(ctx, dto.RequestUser{
+		Name:     args.Input.Name,
+		Birthday: args.Input.Birthday,
+		Contact:  args.Input.Contact,
+	}, id)
+	if err != nil {
+		return nil, toError(err)
+	}
+	return &userResolver{user: usr, r: r}, nil
+}
+
+func (r *resolver) DeleteUser(ctx context.Context, args struct{ ID graphql.ID }) (bool, error) {
+	id, err := r.authorizeUser(ctx, args.ID)
+	if err != nil {
+		return false, toError(err)
+	}
+	if err := r.users.Delete(ctx, id); err != nil {
+		return false, toError(err)
+	}
+	return true, nil
+}
+
+func (r *resolver) SignIn(ctx context.Context, args struct{ Email, Password string }) (string, error) {
+	if err := r.rateLimit(ctx, "signIn"); err != nil {
+		return "", toError(err)
+	}
+	token, err := r.users.GenerateToken(ctx, args.Email, args.Password)
+	if err != nil {
+		return "", toError(err)
+	}
+	return token, nil
+}
+
+// rateLimit charges the caller for one call of the mutation field.
+func (r *resolver) rateLimit(ctx context.Context, field string) error {
+	rule, ok := r.limits[field]
+	if !ok || r.limiter == nil {
+		return nil
+	}
+	return middleware.Charge(ctx, r.limiter, rule)
+}
+
+// authorizeUser checks that the caller is the user with the given id.
+func (r *resolver) authorizeUser(ctx context.Context, rawID graphql.ID) (int, error) {
+	id, err := parseID(rawID)
+	if err != nil {
+		return 0, err
+	}
+	userId, ok := ctx.Value("user_id").(int)
+	if !ok {
+		return 0, errUnauthorized
+	}
+	if userId != id {
+		return 0, errForbidden
+	}
+	return id, nil
+}
+
+func (r *resolver) adResolvers(ads []adDto.ResponseAd) []*adResolver {
+	res := make([]*adResolver, 0, len(ads))
+	for _, ad := range ads {
+		res = append(res, &adResolver{ad: ad, r: r})
+	}
+	return res
+}
+
+type adResolver struct {
+	ad adDto.ResponseAd
+	r  *resolver
+}
+
+func (a *adResolver) ID() graphql.ID {
+	return graphql.ID(strconv.Itoa(a.ad.ID))
+}
+
+func (a *adResolver) Title() string {
+	return a.ad.Title
+}
+
+func (a *adResolver) Description() string {
+	return a.ad.Description
+}
+
+func (a *adResolver) Price() float64 {
+	return float64(a.ad.Price)
+}
+
+func (a *adResolver) Seller(ctx context.Context) (*userResolver, error) {
+	usr, err := loadersFrom(ctx).users.Load(ctx, a.ad.UserID)()
+	if err != nil {
+		if apperror.KindOf(err) == apperror.KindNotFound {
+			return nil, nil
+		}
+		return nil, toError(err)
+	}
+	return &userResolver{user: usr, r: a.r}, nil
+}
+
+type userResolver struct {
+	user dto.ResponseUser
+	r    *resolver
+}
+
+func (u *userResolver) ID() graphql.ID {
+	return graphql.ID(strconv.Itoa(u.user.ID))
+}
+
+func (u *userResolver) Name() string {
+	return u.user.Name
+}
+
+func (u *userResolver) Email() string {
+	return u.user.Email
+}
+
+func (u *userResolver) Birthday() string {
+	return u.user.Birthday
+}
+
+func (u *userResolver) Contact() string {
+	return u.user.Contact
+}
+
+func (u *userResolver) Ads(ctx context.Context) ([]*adResolver, error) {
+	ads, err := loadersFrom(ctx).userAds.Load(ctx, u.user.ID)()
+	if err != nil {
+		return nil, toError(err)
+	}
+	return u.r.adResolvers(ads), nil
+}
+
+func parseID(id graphql.ID) (int, error) {
+	n, err := strconv.Atoi(string(id))
+	if err != nil || n < 1 {
+		return 0, errInvalidID
+	}
+	return n, nil
+}
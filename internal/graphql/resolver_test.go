@@ -0,0 +1,220 @@
+package graphql
+
+import (
+	"bulletin-board/internal/ad"
+	adServ "bulletin-board/internal/ad/service"
+	"bulletin-board/internal/apperror"
+	"bulletin-board/internal/memstore"
+	"bulletin-board/internal/middleware"
+	"bulletin-board/internal/uow"
+	"bulletin-board/internal/user"
+	userServ "bulletin-board/internal/user/service"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// counter counts the lookups the resolvers make.
+type counter struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (c *counter) count(method string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls[method]++
+}
+
+type countingUsers struct {
+	user.Repository
+	*counter
+}
+
+func (c countingUsers) GetByID(ctx context.Context, id int) (user.User, error) {
+	c.count("users.GetByID")
+	return c.Repository.GetByID(ctx, id)
+}
+
+func (c countingUsers) GetByIDs(ctx context.Context, ids []int) ([]user.User, error) {
+	c.count("users.GetByIDs")
+	return c.Repository.GetByIDs(ctx, ids)
+}
+
+type countingAds struct {
+	ad.Repository
+	*counter
+}
+
+func (c countingAds) GetByUserIDs(ctx context.Context, userIDs []int) ([]ad.Ad, error) {
+	c.count("ads.GetByUserIDs")
+	return c.Repository.GetByUserIDs(ctx, userIDs)
+}
+
+func newTestHandler(t *testing.T) (http.Handler, *counter) {
+	t.Helper()
+	ctx := context.Background()
+	db := memstore.New()
+	calls := &counter{calls: make(map[string]int)}
+	users := countingUsers{Repository: db.UserRepository(), counter: calls}
+	ads := countingAds{Repository: db.AdRepository(), counter: calls}
+
+	for i := 1; i <= 3; i++ {
+		seller, err := users.Create(ctx, user.User{
+			Name:     fmt.Sprintf("Seller %d", i),
+			Email:    fmt.Sprintf("seller%d@example.com", i),
+			Birthday: time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j := 0; j < 2; j++ {
+			if _, err := ads.Create(ctx, ad.Ad{Title: "Bike", Price: 5_000_000_000, UserID: seller.ID}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	userService := userServ.NewService(users, ads, uow.NoOp())
+	adService := adServ.NewService(ads, users, uow.NoOp(), nil)
+	r := mux.NewRouter()
+	NewHandler(adService, userService, nil, nil).NewRouter(r)
+	return r, calls
+}
+
+type response struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func query(t *testing.T, handler http.Handler, q string) response {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{"query": q})
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body))))
+	var resp response
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("%d %s: %v", w.Code, w.Body, err)
+	}
+	return resp
+}
+
+func TestLoadersBatchLookups(t *testing.T) {
+	handler, calls := newTestHandler(t)
+
+	resp := query(t, handler, `{ ads { id price seller { id ads { id } } } }`)
+	if len(resp.Errors) > 0 {
+		t.Fatalf("errors: %+v", resp.Errors)
+	}
+	var data struct {
+		Ads []struct {
+			Price  float64
+			Seller struct {
+				ID  string
+				Ads []struct{ ID string }
+			}
+		}
+	}
+	if err := json.Unmarshal(resp.Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Ads) != 6 {
+		t.Fatalf("got %d ads, want 6", len(data.Ads))
+	}
+	for _, a := range data.Ads {
+		if a.Seller.ID == "" || len(a.Seller.Ads) != 2 {
+			t.Fatalf("ad %+v, want its seller with two ads", a)
+		}
+		if a.Price != 5_000_000_000 {
+			t.Fatalf("price = %v, want it not truncated", a.Price)
+		}
+	}
+
+	want := map[string]int{"users.GetByIDs": 1, "ads.GetByUserIDs": 1}
+	if fmt.Sprint(calls.calls) != fmt.Sprint(want) {
+		t.Fatalf("repository calls = %v, want %v", calls.calls, want)
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+	handler, _ := newTestHandler(t)
+
+	deep := "id"
+	for i := 0; i < maxDepth; i++ {
+		deep = "ads { seller { " + deep + " } }"
+	}
+	if resp := query(t, handler, "{ "+deep+" }"); len(resp.Errors) == 0 {
+		t.Fatal("query nested beyond maxDepth was run")
+	}
+}
+
+func TestAdInputPrice(t *testing.T) {
+	tests := map[float64]bool{
+		0:             true,
+		5_000_000_000: true,
+		1.5:           false,
+		1 << 60:       false,
+	}
+	for price, ok := range tests {
+		req, err := adInput{Title: "Bike", Price: price}.toRequest()
+		switch {
+		case ok && (err != nil || float64(req.Price) != price):
+			t.Errorf("price %v = %d, %v, want it kept", price, req.Price, err)
+		case !ok && apperror.KindOf(err) != apperror.KindValidation:
+			t.Errorf("price %v = %v, want a validation error", price, err)
+		}
+	}
+}
+
+// fixedLimiter allows limit.Requests calls per key and never refills.
+type fixedLimiter struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (l *fixedLimiter) Allow(ctx context.Context, key string, limit middleware.Limit) (middleware.RateLimitResult, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calls[key]++
+	return middleware.RateLimitResult{Allowed: l.calls[key] <= limit.Requests}, nil
+}
+
+func TestAuthMutationsChargedPerCall(t *testing.T) {
+	db := memstore.New()
+	userService := userServ.NewService(db.UserRepository(), db.AdRepository(), uow.NoOp())
+	adService := adServ.NewService(db.AdRepository(), db.UserRepository(), uow.NoOp(), nil)
+	limiter := &fixedLimiter{calls: make(map[string]int)}
+	auth := middleware.RateLimitRule{Name: "auth", Limit: middleware.Limit{Requests: 2, Period: time.Minute}}
+	r := mux.NewRouter()
+	NewHandler(adService, userService, limiter, map[string]middleware.RateLimitRule{"signIn": auth}).NewRouter(r)
+
+	// Aliases let one document sign in many times.
+	resp := query(t, r, `mutation {
+		a: signIn(email: "ann@example.com", password: "guess1")
+		b: signIn(email: "ann@example.com", password: "guess2")
+		c: signIn(email: "ann@example.com", password: "guess3")
+	}`)
+
+	var limited int
+	for _, e := range resp.Errors {
+		if e.Extensions["code"] == "TOO_MANY_REQUESTS" {
+			limited++
+		}
+	}
+	if limited != 1 {
+		t.Fatalf("errors = %+v, want the third sign-in rate limited", resp.Errors)
+	}
+	if fmt.Sprint(limiter.calls) != "map[ratelimit:auth:ip:192.0.2.1:3]" {
+		t.Fatalf("limiter calls = %v, want three charges of one caller", limiter.calls)
+	}
+}
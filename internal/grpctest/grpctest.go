@@ -0,0 +1,39 @@
+// Package grpctest serves gRPC services over an in-memory listener for
+// tests.
+package grpctest
+
+import (
+	"bulletin-board/internal/middleware"
+	"bulletin-board/internal/user/service"
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"testing"
+)
+
+// Dial starts a server with the error and auth interceptors of the API,
+// requiring tokens of the user service for the secured methods, lets
+// register add the services under test and returns a connection to it.
+func Dial(t *testing.T, secured []string, register func(*grpc.Server)) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	gs := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		middleware.UnaryErrors,
+		middleware.UnaryAuth(service.SigningKey, secured...),
+	))
+	register(gs)
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
@@ -0,0 +1,92 @@
+// Package httpbody reads request bodies strictly: a body holds exactly
+// one JSON value whose members all belong to the target, and bodies cut
+// off by http.MaxBytesReader are reported as too large.
+package httpbody
+
+import (
+	"bulletin-board/internal/apperror"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+var (
+	ErrTooLarge = apperror.TooLarge("request body is too large")
+
+	errEmpty     = apperror.BadRequest("request body is empty")
+	errMalformed = apperror.BadRequest("request body is not valid JSON")
+	errTrailing  = apperror.BadRequest("request body must hold a single JSON value")
+)
+
+// DecodeJSON decodes the request body into v, rejecting unknown members
+// and anything after the first JSON value.
+func DecodeJSON(r *http.Request, v any) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return decodeError(err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		if err != nil {
+			return decodeError(err)
+		}
+		return errTrailing
+	}
+	return nil
+}
+
+// Read returns the whole request body.
+func Read(r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, apperror.Wrap(ErrTooLarge, err)
+		}
+		return nil, apperror.Wrap(apperror.BadRequest("invalid request body"), err)
+	}
+	return body, nil
+}
+
+func decodeError(err error) error {
+	var (
+		tooLarge  *http.MaxBytesError
+		syntax    *json.SyntaxError
+		fieldType *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.As(err, &tooLarge):
+		return apperror.Wrap(ErrTooLarge, err)
+	case errors.Is(err, io.EOF):
+		return errEmpty
+	case errors.As(err, &syntax):
+		return apperror.Wrap(apperror.BadRequest(fmt.Sprintf("request body is not valid JSON: error at offset %d", syntax.Offset)), err)
+	case errors.As(err, &fieldType) && fieldType.Field != "":
+		return apperror.Wrap(apperror.Validation("invalid request body", apperror.FieldError{Field: fieldType.Field, Message: "must be " + jsonType(fieldType.Type.Kind())}), err)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		return apperror.Wrap(apperror.BadRequest(strings.TrimPrefix(err.Error(), "json: ")), err)
+	}
+	return apperror.Wrap(errMalformed, err)
+}
+
+// jsonType names the JSON type Go values of kind decode from.
+func jsonType(kind reflect.Kind) string {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Struct, reflect.Map:
+		return "an object"
+	}
+	return "a string"
+}
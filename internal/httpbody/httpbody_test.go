@@ -0,0 +1,64 @@
+package httpbody
+
+import (
+	"bulletin-board/internal/apperror"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type input struct {
+	Title string `json:"title"`
+	Price int    `json:"price"`
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := map[string]apperror.Kind{
+		`{"title":"Bike","price":5}`:       -1,
+		" {\"title\":\"Bike\"}\n":          -1,
+		``:                                 apperror.KindBadRequest,
+		`{"title":`:                        apperror.KindBadRequest,
+		`{"title":"Bike"}}`:                apperror.KindBadRequest,
+		`{"title":"Bike"} {"title":"Car"}`: apperror.KindBadRequest,
+		`{"title":"Bike","owner":1}`:       apperror.KindBadRequest,
+		`{"price":"5"}`:                    apperror.KindValidation,
+	}
+	for body, want := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/ads", strings.NewReader(body))
+		var v input
+		err := DecodeJSON(r, &v)
+		switch {
+		case want < 0 && err != nil:
+			t.Errorf("DecodeJSON(%q) = %v, want success", body, err)
+		case want >= 0 && (err == nil || apperror.KindOf(err) != want):
+			t.Errorf("DecodeJSON(%q) = %v, want kind %d", body, err, want)
+		}
+	}
+}
+
+func TestDecodeJSONFieldType(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/ads", strings.NewReader(`{"price":1.5}`))
+	var v input
+	appErr, ok := apperror.As(DecodeJSON(r, &v))
+	if !ok || len(appErr.Fields) != 1 || appErr.Fields[0] != (apperror.FieldError{Field: "price", Message: "must be an integer"}) {
+		t.Fatalf("DecodeJSON = %v, want a field error for price", appErr)
+	}
+}
+
+func TestTooLarge(t *testing.T) {
+	newRequest := func() *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/ads", strings.NewReader(`{"title":"`+strings.Repeat("x", 100)+`"}`))
+		r.Body = http.MaxBytesReader(httptest.NewRecorder(), r.Body, 32)
+		return r
+	}
+
+	var v input
+	if err := DecodeJSON(newRequest(), &v); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("DecodeJSON = %v, want ErrTooLarge", err)
+	}
+	if _, err := Read(newRequest()); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Read = %v, want ErrTooLarge", err)
+	}
+}
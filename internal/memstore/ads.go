@@ -0,0 +1,123 @@
+package memstore
+
+import (
+	"bulletin-board/internal/ad"
+	"context"
+	"slices"
+	"time"
+)
+
+type adRepository struct {
+	db *DB
+}
+
+func (r adRepository) GetAll(ctx context.Context) ([]ad.Ad, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	ads := make([]ad.Ad, 0, len(r.db.ads))
+	for _, a := range r.db.ads {
+		ads = append(ads, a)
+	}
+	slices.SortFunc(ads, func(a, b ad.Ad) int { return a.ID - b.ID })
+	return ads, nil
+}
+
+func (r adRepository) GetByUserIDs(ctx context.Context, userIDs []int) ([]ad.Ad, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	ads := make([]ad.Ad, 0)
+	for _, a := range r.db.ads {
+		if slices.Contains(userIDs, a.UserID) {
+			ads = append(ads, a)
+		}
+	}
+	slices.SortFunc(ads, func(a, b ad.Ad) int { return a.ID - b.ID })
+	return ads, nil
+}
+
+func (r adRepository) GetByID(ctx context.Context, ID int) (ad.Ad, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	a, ok := r.db.ads[ID]
+	if !ok {
+		return ad.Ad{}, ad.ErrNotFound
+	}
+	return a, nil
+}
+
+func (r adRepository) Create(ctx context.Context, newAd ad.Ad) (ad.Ad, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.users[newAd.UserID]; !ok {
+		return ad.Ad{}, ad.ErrUnknownUser
+	}
+
+	r.db.nextAdID++
+	newAd.ID = r.db.nextAdID
+	newAd.Version = 1
+	newAd.CreatedAt = time.Now().UTC()
+	newAd.UpdatedAt = newAd.CreatedAt
+	r.db.ads[newAd.ID] = newAd
+
+	history := r.db.creations[newAd.UserID]
+	cutoff := newAd.CreatedAt.Add(-ad.CreationHistory)
+	for len(history) > 0 && history[0].Before(cutoff) {
+		history = history[1:]
+	}
+	r.db.creations[newAd.UserID] = append(history, newAd.CreatedAt)
+	return newAd, nil
+}
+
+func (r adRepository) Update(ctx context.Context, newAd ad.Ad, id int) (ad.Ad, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	stored, ok := r.db.ads[id]
+	if !ok {
+		return ad.Ad{}, ad.ErrNotFound
+	}
+	if newAd.Version != 0 && newAd.Version != stored.Version {
+		return ad.Ad{}, ad.ErrVersionMismatch
+	}
+
+	stored.Title = newAd.Title
+	stored.Description = newAd.Description
+	stored.Price = newAd.Price
+	stored.Version++
+	stored.UpdatedAt = time.Now().UTC()
+	r.db.ads[id] = stored
+	return stored, nil
+}
+
+func (r adRepository) CountByUser(ctx context.Context, userID int, since time.Time) (ad.Usage, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	var usage ad.Usage
+	for _, a := range r.db.ads {
+		if a.UserID == userID {
+			usage.Active++
+		}
+	}
+	for _, createdAt := range r.db.creations[userID] {
+		if !createdAt.Before(since) {
+			usage.Created++
+		}
+	}
+	return usage, nil
+}
+
+func (r adRepository) Delete(ctx context.Context, id int) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.ads[id]; !ok {
+		return ad.ErrNotFound
+	}
+	delete(r.db.ads, id)
+	return nil
+}
@@ -0,0 +1,37 @@
+package memstore
+
+import (
+	"bulletin-board/internal/ad"
+	"bulletin-board/internal/user"
+	"sync"
+	"time"
+)
+
+// DB is a thread-safe in-memory database shared by the ad and user
+// repositories, so that foreign keys behave like the Postgres schema:
+// ads must reference an existing user and are deleted with their owner.
+type DB struct {
+	mu    sync.RWMutex
+	ads   map[int]ad.Ad
+	users map[int]user.User
+	// creations holds when each user created ads, oldest first.
+	creations  map[int][]time.Time
+	nextAdID   int
+	nextUserID int
+}
+
+func New() *DB {
+	return &DB{
+		ads:       make(map[int]ad.Ad),
+		users:     make(map[int]user.User),
+		creations: make(map[int][]time.Time),
+	}
+}
+
+func (db *DB) AdRepository() ad.Repository {
+	return adRepository{db: db}
+}
+
+func (db *DB) UserRepository() user.Repository {
+	return userRepository{db: db}
+}
@@ -0,0 +1,42 @@
+package memstore_test
+
+import (
+	"bulletin-board/internal/ad"
+	adRepotest "bulletin-board/internal/ad/repository/repotest"
+	"bulletin-board/internal/memstore"
+	"bulletin-board/internal/user"
+	userRepotest "bulletin-board/internal/user/repotest"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAdRepository(t *testing.T) {
+	adRepotest.Run(t, func(t *testing.T) (ad.Repository, int) {
+		db := memstore.New()
+		owner, err := db.UserRepository().Create(context.Background(), user.User{
+			Name:     "Owner",
+			Email:    "owner@example.com",
+			Birthday: time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return db.AdRepository(), owner.ID
+	})
+}
+
+func TestUserRepository(t *testing.T) {
+	userRepotest.Run(t, func(t *testing.T) (user.Repository, ad.Repository) {
+		db := memstore.New()
+		return db.UserRepository(), db.AdRepository()
+	})
+}
+
+func TestCreateAdUnknownUser(t *testing.T) {
+	_, err := memstore.New().AdRepository().Create(context.Background(), ad.Ad{Title: "orphan", UserID: 1})
+	if !errors.Is(err, ad.ErrUnknownUser) {
+		t.Fatalf("Create error = %v, want ad.ErrUnknownUser", err)
+	}
+}
@@ -0,0 +1,123 @@
+package memstore
+
+import (
+	"bulletin-board/internal/user"
+	"context"
+	"slices"
+	"time"
+)
+
+type userRepository struct {
+	db *DB
+}
+
+func (r userRepository) GetAll(ctx context.Context) ([]user.User, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	users := make([]user.User, 0, len(r.db.users))
+	for _, u := range r.db.users {
+		users = append(users, withoutPassword(u))
+	}
+	slices.SortFunc(users, func(a, b user.User) int { return a.ID - b.ID })
+	return users, nil
+}
+
+func (r userRepository) GetByID(ctx context.Context, id int) (user.User, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	u, ok := r.db.users[id]
+	if !ok {
+		return user.User{}, user.ErrUserNotFound
+	}
+	return withoutPassword(u), nil
+}
+
+func (r userRepository) GetByIDs(ctx context.Context, ids []int) ([]user.User, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	users := make([]user.User, 0, len(ids))
+	for _, id := range ids {
+		if u, ok := r.db.users[id]; ok {
+			users = append(users, withoutPassword(u))
+		}
+	}
+	slices.SortFunc(users, func(a, b user.User) int { return a.ID - b.ID })
+	return users, nil
+}
+
+func (r userRepository) GetByEmail(ctx context.Context, email string) (user.User, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	for _, u := range r.db.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return user.User{}, user.ErrUserNotFound
+}
+
+func (r userRepository) Create(ctx context.Context, newUser user.User) (user.User, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for _, u := range r.db.users {
+		if u.Email == newUser.Email {
+			return user.User{}, user.ErrEmailTaken
+		}
+	}
+
+	r.db.nextUserID++
+	newUser.ID = r.db.nextUserID
+	newUser.Tier = user.TierBasic
+	newUser.Version = 1
+	newUser.UpdatedAt = time.Now().UTC()
+	r.db.users[newUser.ID] = newUser
+	return newUser, nil
+}
+
+func (r userRepository) Update(ctx context.Context, newUser user.User, id int) (user.User, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	stored, ok := r.db.users[id]
+	if !ok {
+		return user.User{}, user.ErrUserNotFound
+	}
+
+	stored.Name = newUser.Name
+	stored.Birthday = newUser.Birthday
+	stored.Contact = newUser.Contact
+	stored.Version++
+	stored.UpdatedAt = time.Now().UTC()
+	r.db.users[id] = stored
+
+	stored.Password = ""
+	return stored, nil
+}
+
+func (r userRepository) Delete(ctx context.Context, id int) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.users[id]; !ok {
+		return user.ErrUserNotFound
+	}
+	delete(r.db.users, id)
+	delete(r.db.creations, id)
+
+	for adID, a := range r.db.ads {
+		if a.UserID == id {
+			delete(r.db.ads, adID)
+		}
+	}
+	return nil
+}
+
+func withoutPassword(u user.User) user.User {
+	u.Password = ""
+	return u
+}
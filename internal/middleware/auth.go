@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"bulletin-board/internal/apperror"
 	"bulletin-board/internal/user/service"
 	"context"
 	"github.com/dgrijalva/jwt-go"
@@ -8,28 +9,64 @@ import (
 	"strings"
 )
 
+var (
+	errTokenMissing = apperror.Unauthorized("token not exist")
+	errTokenInvalid = apperror.Unauthorized("invalid token")
+)
+
 func AuthMiddleware(signingKey string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			token := r.Header.Get("Authorization")
-			if token == "" {
-				http.Error(w, "token not exist", http.StatusUnauthorized)
+			userId, err := ParseToken(signingKey, r.Header.Get("Authorization"))
+			if err != nil {
+				apperror.Write(w, r, err)
 				return
 			}
 
-			token = strings.TrimPrefix(token, "Bearer ")
-			claims := &service.TokenClaims{}
-			parsedToken, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
-				return []byte(signingKey), nil
-			})
+			ctx := context.WithValue(r.Context(), "user_id", userId)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
 
-			if err != nil || !parsedToken.Valid {
-				http.Error(w, "invalid token", http.StatusUnauthorized)
+// OptionalAuth stores the user id in the context when a valid token is
+// sent, but lets anonymous requests through.
+func OptionalAuth(signingKey string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			if header == "" {
+				next.ServeHTTP(w, r)
 				return
 			}
 
-			ctx := context.WithValue(r.Context(), "user_id", claims.UserId)
+			userId, err := ParseToken(signingKey, header)
+			if err != nil {
+				apperror.Write(w, r, err)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), "user_id", userId)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
+
+// ParseToken validates a "Bearer <jwt>" authorization value and returns
+// the user id from its claims.
+func ParseToken(signingKey, header string) (int, error) {
+	if header == "" {
+		return 0, errTokenMissing
+	}
+
+	token := strings.TrimPrefix(header, "Bearer ")
+	claims := &service.TokenClaims{}
+	parsedToken, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(signingKey), nil
+	})
+
+	if err != nil || !parsedToken.Valid {
+		return 0, errTokenInvalid
+	}
+	return claims.UserId, nil
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"bulletin-board/internal/apperror"
+	"bulletin-board/internal/httpbody"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+var errNotJSON = apperror.UnsupportedMediaType("request body must be JSON")
+
+// LimitBody rejects request bodies larger than limit bytes. Bodies of
+// unknown length are cut off once they pass the limit, which handlers
+// see as httpbody.ErrTooLarge.
+func LimitBody(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				apperror.Write(w, r, httpbody.ErrTooLarge)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireJSON rejects POST, PUT and PATCH requests whose body is not
+// application/json or another JSON media type such as
+// application/merge-patch+json.
+func RequireJSON(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+			if r.ContentLength != 0 && !isJSON(r.Header.Get("Content-Type")) {
+				apperror.Write(w, r, errNotJSON)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || (strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json"))
+}
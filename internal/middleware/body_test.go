@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"bulletin-board/internal/httpbody"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLimitBody(t *testing.T) {
+	handler := LimitBody(16)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := httpbody.Read(r); err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		}
+	}))
+
+	serve := func(body string, chunked bool) int {
+		r := httptest.NewRequest(http.MethodPost, "/ads", strings.NewReader(body))
+		if chunked {
+			r.Body = io.NopCloser(strings.NewReader(body))
+			r.ContentLength = -1
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if code := serve(`{"title":"Bike"}`, false); code != http.StatusOK {
+		t.Fatalf("body at the limit = %d", code)
+	}
+	for _, chunked := range []bool{false, true} {
+		if code := serve(`{"title":"Bicycle"}`, chunked); code != http.StatusRequestEntityTooLarge {
+			t.Errorf("body over the limit (chunked %v) = %d, want 413", chunked, code)
+		}
+	}
+}
+
+func TestRequireJSON(t *testing.T) {
+	handler := RequireJSON(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		method, contentType, body string
+		want                      int
+	}{
+		{http.MethodPost, "application/json", `{}`, http.StatusOK},
+		{http.MethodPut, "application/json; charset=utf-8", `{}`, http.StatusOK},
+		{http.MethodPatch, "application/merge-patch+json", `{}`, http.StatusOK},
+		{http.MethodPost, "", ``, http.StatusOK},
+		{http.MethodGet, "text/plain", `x`, http.StatusOK},
+		{http.MethodPost, "", `{}`, http.StatusUnsupportedMediaType},
+		{http.MethodPost, "text/plain", `{}`, http.StatusUnsupportedMediaType},
+		{http.MethodPut, "application/x-www-form-urlencoded", `a=b`, http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, "/ads", strings.NewReader(tt.body))
+		if tt.contentType != "" {
+			r.Header.Set("Content-Type", tt.contentType)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != tt.want {
+			t.Errorf("%s with %q = %d, want %d", tt.method, tt.contentType, w.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"bulletin-board/internal/apperror"
+	"bulletin-board/pkg/logger"
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"runtime/debug"
+	"time"
+)
+
+// UnaryLogging is the gRPC counterpart of RequestID and AccessLog.
+func UnaryLogging(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+
+	id := firstMetadata(ctx, "x-request-id")
+	if id == "" || len(id) > 128 {
+		id = newRequestID()
+	}
+	ctx = logger.WithRequestID(ctx, id)
+	_ = grpc.SetHeader(ctx, metadata.Pairs("x-request-id", id))
+
+	resp, err := handler(ctx, req)
+
+	code := status.Code(err)
+	slog.InfoContext(ctx, "grpc request",
+		slog.String("method", info.FullMethod),
+		slog.String("code", code.String()),
+		slog.Duration("duration", time.Since(start)),
+	)
+	return resp, err
+}
+
+// UnaryRecover is the gRPC counterpart of Recover.
+func UnaryRecover(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			slog.ErrorContext(ctx, "grpc handler panicked",
+				slog.String("method", info.FullMethod),
+				slog.Any("panic", v),
+				slog.String("stack", string(debug.Stack())),
+			)
+			resp, err = nil, status.Error(codes.Internal, "internal server error")
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// UnaryErrors converts errors returned by handlers to gRPC statuses.
+func UnaryErrors(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	resp, err := handler(ctx, req)
+	if err == nil {
+		return resp, nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return resp, err
+	}
+
+	if apperror.KindOf(err) == apperror.KindInternal {
+		slog.ErrorContext(ctx, "grpc request failed", slog.String("method", info.FullMethod), slog.Any("error", err))
+	}
+	return nil, apperror.GRPCStatus(err)
+}
+
+// UnaryAuth requires a valid bearer token for the given full method names
+// and stores the user id in the context like AuthMiddleware does.
+func UnaryAuth(signingKey string, secured ...string) grpc.UnaryServerInterceptor {
+	methods := make(map[string]bool, len(secured))
+	for _, m := range secured {
+		methods[m] = true
+	}
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !methods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		userId, err := ParseToken(signingKey, firstMetadata(ctx, "authorization"))
+		if err != nil {
+			return nil, err
+		}
+
+		ctx = context.WithValue(ctx, "user_id", userId)
+		return handler(ctx, req)
+	}
+}
+
+func firstMetadata(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"bulletin-board/internal/apperror"
+	"context"
+	"errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestUnaryErrors(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/bulletin.v1.AdService/GetAd"}
+	call := func(err error) error {
+		_, got := UnaryErrors(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+			return nil, err
+		})
+		return got
+	}
+
+	tests := []struct {
+		err  error
+		code codes.Code
+		msg  string
+	}{
+		{apperror.NotFound("ad not found"), codes.NotFound, "ad not found"},
+		{apperror.Forbidden("forbidden"), codes.PermissionDenied, "forbidden"},
+		{context.DeadlineExceeded, codes.DeadlineExceeded, ""},
+		{errors.New("connection refused"), codes.Internal, "internal server error"},
+		{status.Error(codes.Aborted, "kept"), codes.Aborted, "kept"},
+	}
+	for _, tt := range tests {
+		st := status.Convert(call(tt.err))
+		if st.Code() != tt.code || (tt.msg != "" && st.Message() != tt.msg) {
+			t.Errorf("%v = %v %q, want %v %q", tt.err, st.Code(), st.Message(), tt.code, tt.msg)
+		}
+	}
+	if err := call(nil); err != nil {
+		t.Errorf("success = %v", err)
+	}
+}
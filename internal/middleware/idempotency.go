@@ -0,0 +1,190 @@
+package middleware
+
+import (
+	"bulletin-board/internal/apperror"
+	"bulletin-board/internal/httpbody"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	IdempotentReplayed   = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+var (
+	errIdempotencyKeyTooLong = apperror.BadRequest("Idempotency-Key must be at most 255 characters")
+	errIdempotencyKeyReused  = apperror.Unprocessable("Idempotency-Key was already used for a different request")
+	errIdempotencyInProgress = apperror.Conflict("a request with this Idempotency-Key is still being processed")
+)
+
+// IdempotentResponse is what an IdempotencyStore keeps per key: the
+// fingerprint of the first request and, once it has finished, the
+// response to replay.
+type IdempotentResponse struct {
+	Fingerprint string      `json:"fingerprint"`
+	Done        bool        `json:"done"`
+	Status      int         `json:"status,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+}
+
+type IdempotencyStore interface {
+	// Reserve stores pending under key for ttl unless the key is taken,
+	// in which case it returns the stored response and false.
+	Reserve(ctx context.Context, key string, pending IdempotentResponse, ttl time.Duration) (IdempotentResponse, bool, error)
+	Save(ctx context.Context, key string, resp IdempotentResponse, ttl time.Duration) error
+	Release(ctx context.Context, key string) error
+}
+
+// Idempotency makes POST requests to the given routes that carry an
+// Idempotency-Key safe to retry: the first response is kept for ttl and
+// replayed to retries with the same key and body. Keys are scoped to the
+// Authorization header. A key is only held for lockTTL while its request
+// runs, so a crashed request does not block retries for long. Server
+// errors and responses marked Cache-Control: no-store are not kept, and
+// if the store is unavailable requests run unprotected.
+func Idempotency(store IdempotencyStore, lockTTL, ttl time.Duration, routes ...func(r *http.Request) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if r.Method != http.MethodPost || key == "" || !slices.ContainsFunc(routes, func(match func(*http.Request) bool) bool { return match(r) }) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				apperror.Write(w, r, errIdempotencyKeyTooLong)
+				return
+			}
+
+			body, err := httpbody.Read(r)
+			if err != nil {
+				apperror.Write(w, r, err)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			ctx := r.Context()
+			key = idempotencyKey(r, key)
+			fingerprint := requestFingerprint(r, body)
+
+			stored, reserved, err := store.Reserve(ctx, key, IdempotentResponse{Fingerprint: fingerprint}, lockTTL)
+			if err != nil {
+				slog.WarnContext(ctx, "idempotency store unavailable", slog.Any("error", err))
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !reserved {
+				switch {
+				case stored.Fingerprint != fingerprint:
+					apperror.Write(w, r, errIdempotencyKeyReused)
+				case !stored.Done:
+					apperror.Write(w, r, errIdempotencyInProgress)
+				default:
+					replay(w, stored)
+				}
+				return
+			}
+
+			before := w.Header().Clone()
+			rec := &responseRecorder{statusRecorder: statusRecorder{ResponseWriter: w, status: http.StatusOK}}
+			completed := false
+			defer func() {
+				// A panicking handler must not leave the key pending.
+				if !completed {
+					release(ctx, store, key)
+				}
+			}()
+
+			next.ServeHTTP(rec, r)
+			completed = true
+
+			if rec.status >= http.StatusInternalServerError || hasNoStore(w.Header()) {
+				release(ctx, store, key)
+				return
+			}
+			resp := IdempotentResponse{
+				Fingerprint: fingerprint,
+				Done:        true,
+				Status:      rec.status,
+				Header:      addedHeaders(before, w.Header()),
+				Body:        rec.body.Bytes(),
+			}
+			if err := store.Save(context.WithoutCancel(ctx), key, resp, ttl); err != nil {
+				slog.WarnContext(ctx, "error to save idempotent response", slog.Any("error", err))
+			}
+		})
+	}
+}
+
+func replay(w http.ResponseWriter, resp IdempotentResponse) {
+	for k, v := range resp.Header {
+		w.Header()[k] = v
+	}
+	w.Header().Set(IdempotentReplayed, "true")
+	w.WriteHeader(resp.Status)
+	_, _ = w.Write(resp.Body)
+}
+
+func release(ctx context.Context, store IdempotencyStore, key string) {
+	if err := store.Release(context.WithoutCancel(ctx), key); err != nil {
+		slog.WarnContext(ctx, "error to release idempotency key", slog.Any("error", err))
+	}
+}
+
+// idempotencyKey scopes key to the caller's credentials, so clients
+// cannot replay each other's responses by guessing keys.
+func idempotencyKey(r *http.Request, key string) string {
+	scope := sha256.Sum256([]byte(r.Header.Get("Authorization")))
+	return "idempotency:" + hex.EncodeToString(scope[:8]) + ":" + key
+}
+
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// addedHeaders returns the headers the handler set, leaving out those
+// of outer middleware such as the request id.
+func addedHeaders(before, after http.Header) http.Header {
+	added := make(http.Header)
+	for k, v := range after {
+		if !slices.Equal(before[k], v) {
+			added[k] = slices.Clone(v)
+		}
+	}
+	return added
+}
+
+func hasNoStore(h http.Header) bool {
+	for _, directive := range strings.Split(h.Get("Cache-Control"), ",") {
+		if strings.EqualFold(strings.TrimSpace(directive), "no-store") {
+			return true
+		}
+	}
+	return false
+}
+
+// responseRecorder passes the response through while keeping a copy of
+// the body.
+type responseRecorder struct {
+	statusRecorder
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.statusRecorder.Write(b)
+}
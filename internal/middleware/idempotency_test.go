@@ -0,0 +1,188 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memoryIdempotencyStore struct {
+	mu        sync.Mutex
+	responses map[string]IdempotentResponse
+	ttls      map[string]time.Duration
+	err       error
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{responses: make(map[string]IdempotentResponse), ttls: make(map[string]time.Duration)}
+}
+
+func (s *memoryIdempotencyStore) Reserve(ctx context.Context, key string, pending IdempotentResponse, ttl time.Duration) (IdempotentResponse, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return IdempotentResponse{}, false, s.err
+	}
+	if stored, ok := s.responses[key]; ok {
+		return stored, false, nil
+	}
+	s.responses[key] = pending
+	s.ttls[key] = ttl
+	return IdempotentResponse{}, true, nil
+}
+
+func (s *memoryIdempotencyStore) Save(ctx context.Context, key string, resp IdempotentResponse, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses[key] = resp
+	s.ttls[key] = ttl
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.responses, key)
+	return nil
+}
+
+func TestIdempotency(t *testing.T) {
+	var calls int
+	status := http.StatusCreated
+	store := newMemoryIdempotencyStore()
+	handler := RequestID(Idempotency(store, time.Second, time.Hour, Route(http.MethodPost, "/ads"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"id":%d}`, calls)
+	})))
+
+	post := func(key, auth, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/ads", strings.NewReader(body))
+		r.Header.Set(IdempotencyKeyHeader, key)
+		r.Header.Set("Authorization", auth)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	first := post("k1", "Bearer a", `{"title":"Bike"}`)
+	if first.Code != http.StatusCreated || first.Body.String() != `{"id":1}` {
+		t.Fatalf("first = %d %s", first.Code, first.Body)
+	}
+
+	retry := post("k1", "Bearer a", `{"title":"Bike"}`)
+	if calls != 1 || retry.Code != http.StatusCreated || retry.Body.String() != `{"id":1}` {
+		t.Fatalf("retry = %d %s after %d calls", retry.Code, retry.Body, calls)
+	}
+	if retry.Header().Get(IdempotentReplayed) != "true" || retry.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("retry headers = %v", retry.Header())
+	}
+	if retry.Header().Get(RequestIDHeader) == first.Header().Get(RequestIDHeader) {
+		t.Fatal("replay reused the request id of the first response")
+	}
+
+	for key, ttl := range store.ttls {
+		if ttl != time.Hour {
+			t.Fatalf("response %s kept for %v, want the idempotency window", key, ttl)
+		}
+	}
+
+	if reused := post("k1", "Bearer a", `{"title":"Boat"}`); reused.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("reused key = %d, want 422", reused.Code)
+	}
+	if other := post("k1", "Bearer b", `{"title":"Bike"}`); other.Code != http.StatusCreated || calls != 2 {
+		t.Fatalf("other caller = %d after %d calls, want a new request", other.Code, calls)
+	}
+
+	status = http.StatusInternalServerError
+	post("k2", "Bearer a", `{}`)
+	status = http.StatusCreated
+	if again := post("k2", "Bearer a", `{}`); again.Code != http.StatusCreated || calls != 4 {
+		t.Fatalf("retry after server error = %d after %d calls, want it to run again", again.Code, calls)
+	}
+}
+
+func TestIdempotencyInProgress(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	started, release := make(chan struct{}), make(chan struct{})
+	handler := Idempotency(store, time.Second, time.Hour, Route(http.MethodPost, "/users"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	newRequest := func() *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{}`))
+		r.Header.Set(IdempotencyKeyHeader, "k")
+		return r
+	}
+
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest())
+		close(done)
+	}()
+	<-started
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newRequest())
+	if w.Code != http.StatusConflict {
+		t.Fatalf("concurrent retry = %d, want 409", w.Code)
+	}
+	for key, ttl := range store.ttls {
+		if ttl != time.Second {
+			t.Fatalf("pending key %s held for %v, want the lock window", key, ttl)
+		}
+	}
+	close(release)
+	<-done
+}
+
+func TestIdempotencyPassThrough(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	var calls int
+	handler := Idempotency(store, time.Second, time.Hour, Route(http.MethodPost, "/users"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path == "/users" {
+			w.Header().Set("Cache-Control", "no-store")
+		}
+	}))
+
+	serve := func(method, key string) {
+		r := httptest.NewRequest(method, "/users", strings.NewReader(`{}`))
+		if key != "" {
+			r.Header.Set(IdempotencyKeyHeader, key)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	serve(http.MethodPost, "")
+	serve(http.MethodPut, "k")
+	serve(http.MethodPost, "k")
+	serve(http.MethodPost, "k")
+	if calls != 4 || len(store.responses) != 0 {
+		t.Fatalf("%d calls and %d stored responses, want 4 and none", calls, len(store.responses))
+	}
+
+	for i := 0; i < 2; i++ {
+		r := httptest.NewRequest(http.MethodPost, "/sign-in", strings.NewReader(`{}`))
+		r.Header.Set(IdempotencyKeyHeader, "k")
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	if calls != 6 || len(store.responses) != 0 {
+		t.Fatalf("%d calls and %d stored responses, want other routes left alone", calls, len(store.responses))
+	}
+
+	store.err = errors.New("redis is down")
+	serve(http.MethodPost, "k")
+	if calls != 7 {
+		t.Fatal("request did not run while the store was unavailable")
+	}
+}
@@ -0,0 +1,219 @@
+package middleware
+
+import (
+	"bulletin-board/internal/apperror"
+	"context"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var errRateLimited = apperror.TooManyRequests("rate limit exceeded")
+
+// Limit allows Requests per Period, spread evenly, with bursts of up to
+// Requests at once.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// ParseLimit reads limits such as "10/m", "1000/h" or "5/30s". "off"
+// and "0" disable the limit.
+func ParseLimit(s string) (Limit, error) {
+	if s == "off" || s == "0" {
+		return Limit{}, nil
+	}
+	count, per, ok := strings.Cut(s, "/")
+	requests, err := strconv.Atoi(count)
+	if !ok || err != nil || requests < 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q", s)
+	}
+
+	var period time.Duration
+	switch per {
+	case "s":
+		period = time.Second
+	case "m":
+		period = time.Minute
+	case "h":
+		period = time.Hour
+	case "d":
+		period = 24 * time.Hour
+	default:
+		if period, err = time.ParseDuration(per); err != nil || period <= 0 {
+			return Limit{}, fmt.Errorf("invalid rate limit %q", s)
+		}
+	}
+	return Limit{Requests: requests, Period: period}, nil
+}
+
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+type RateLimitResult struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter is how long a rejected caller has to wait.
+	RetryAfter time.Duration
+	// Reset is how long until the caller's full allowance is back.
+	Reset time.Duration
+}
+
+type RateLimiter interface {
+	Allow(ctx context.Context, key string, limit Limit) (RateLimitResult, error)
+}
+
+// RateLimitRule limits the requests Match selects. The first matching
+// rule applies; requests no rule matches are not limited.
+type RateLimitRule struct {
+	Name  string
+	Match func(r *http.Request) bool
+	Limit Limit
+}
+
+// Route matches requests with the given method whose path is prefix or
+// lies below it. An empty method matches any method.
+func Route(method, prefix string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		if method != "" && r.Method != method {
+			return false
+		}
+		path := r.URL.Path
+		return path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/")
+	}
+}
+
+// RateLimit limits requests per rule and caller. Callers are identified
+// by the user id of a valid bearer token, otherwise by client IP.
+// Requests are let through if the limiter fails.
+func RateLimit(limiter RateLimiter, signingKey string, rules ...RateLimitRule) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rule, ok := matchRule(rules, r)
+			if !ok || !rule.Limit.Enabled() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			res, err := limiter.Allow(r.Context(), rateLimitKey(rule, caller(signingKey, r.Header.Get("Authorization"), r.RemoteAddr)), rule.Limit)
+			if err != nil {
+				slog.DebugContext(r.Context(), "rate limiter unavailable", slog.Any("error", err))
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(rule.Limit.Requests))
+			h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("RateLimit-Reset", seconds(res.Reset))
+			h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", rule.Limit.Requests, int(rule.Limit.Period.Seconds())))
+			if !res.Allowed {
+				h.Set("Retry-After", seconds(res.RetryAfter))
+				apperror.Write(w, r, errRateLimited)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// UnaryRateLimit is the gRPC counterpart of RateLimit. methods maps full
+// method names to their rule, whose Match is not used; a rule named like
+// an HTTP rule shares its allowance.
+func UnaryRateLimit(limiter RateLimiter, signingKey string, methods map[string]RateLimitRule) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		rule, ok := methods[info.FullMethod]
+		if !ok || !rule.Limit.Enabled() {
+			return handler(ctx, req)
+		}
+
+		var remoteAddr string
+		if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+			remoteAddr = p.Addr.String()
+		}
+		res, err := limiter.Allow(ctx, rateLimitKey(rule, caller(signingKey, firstMetadata(ctx, "authorization"), remoteAddr)), rule.Limit)
+		if err != nil {
+			slog.DebugContext(ctx, "rate limiter unavailable", slog.Any("error", err))
+			return handler(ctx, req)
+		}
+		if !res.Allowed {
+			_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", seconds(res.RetryAfter)))
+			return nil, errRateLimited
+		}
+		return handler(ctx, req)
+	}
+}
+
+type callerKey struct{}
+
+// RateLimitCaller stores the caller of each request in its context, for
+// handlers that run several limited operations per request, such as
+// GraphQL mutations, and charge each with Charge.
+func RateLimitCaller(signingKey string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), callerKey{}, caller(signingKey, r.Header.Get("Authorization"), r.RemoteAddr))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Charge counts one operation of the caller stored by RateLimitCaller
+// against rule. Like RateLimit, it lets the operation through if the
+// limiter fails.
+func Charge(ctx context.Context, limiter RateLimiter, rule RateLimitRule) error {
+	who, ok := ctx.Value(callerKey{}).(string)
+	if !ok || !rule.Limit.Enabled() {
+		return nil
+	}
+	res, err := limiter.Allow(ctx, rateLimitKey(rule, who), rule.Limit)
+	if err != nil {
+		slog.DebugContext(ctx, "rate limiter unavailable", slog.Any("error", err))
+		return nil
+	}
+	if !res.Allowed {
+		return errRateLimited
+	}
+	return nil
+}
+
+func matchRule(rules []RateLimitRule, r *http.Request) (RateLimitRule, bool) {
+	for _, rule := range rules {
+		if rule.Match(r) {
+			return rule, true
+		}
+	}
+	return RateLimitRule{}, false
+}
+
+func rateLimitKey(rule RateLimitRule, caller string) string {
+	return "ratelimit:" + rule.Name + ":" + caller
+}
+
+func caller(signingKey, authorization, remoteAddr string) string {
+	if authorization != "" {
+		if userId, err := ParseToken(signingKey, authorization); err == nil {
+			return "user:" + strconv.Itoa(userId)
+		}
+	}
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	return "ip:" + host
+}
+
+// seconds rounds d up to whole seconds, so that clients waiting that
+// long are not rejected again.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"bulletin-board/internal/apperror"
+	"context"
+	"errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// countingLimiter allows limit.Requests calls per key and never refills.
+type countingLimiter struct {
+	calls map[string]int
+	err   error
+}
+
+func (l *countingLimiter) Allow(ctx context.Context, key string, limit Limit) (RateLimitResult, error) {
+	if l.err != nil {
+		return RateLimitResult{}, l.err
+	}
+	l.calls[key]++
+	if l.calls[key] > limit.Requests {
+		return RateLimitResult{RetryAfter: 1500 * time.Millisecond, Reset: limit.Period}, nil
+	}
+	return RateLimitResult{Allowed: true, Remaining: limit.Requests - l.calls[key], Reset: limit.Period}, nil
+}
+
+func TestRateLimit(t *testing.T) {
+	limiter := &countingLimiter{calls: make(map[string]int)}
+	handler := RateLimit(limiter, "key",
+		RateLimitRule{Name: "auth", Match: Route(http.MethodPost, "/sign-in"), Limit: Limit{Requests: 2, Period: time.Minute}},
+		RateLimitRule{Name: "read", Match: Route(http.MethodGet, "/ads"), Limit: Limit{Requests: 100, Period: time.Minute}},
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func(method, path, remoteAddr string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, nil)
+		r.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := serve(http.MethodPost, "/sign-in", "10.0.0.1:1234"); w.Code != http.StatusOK {
+			t.Fatalf("request %d = %d", i, w.Code)
+		}
+	}
+	w := serve(http.MethodPost, "/sign-in", "10.0.0.1:5678")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("third request = %d, want 429", w.Code)
+	}
+	for header, want := range map[string]string{
+		"Retry-After":         "2",
+		"RateLimit-Limit":     "2",
+		"RateLimit-Remaining": "0",
+		"RateLimit-Reset":     "60",
+		"RateLimit-Policy":    "2;w=60",
+	} {
+		if got := w.Header().Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+
+	if w := serve(http.MethodPost, "/sign-in", "10.0.0.2:1234"); w.Code != http.StatusOK {
+		t.Fatalf("other client = %d, want its own allowance", w.Code)
+	}
+	if w := serve(http.MethodGet, "/ads/1", "10.0.0.1:1234"); w.Code != http.StatusOK || w.Header().Get("RateLimit-Remaining") != "99" {
+		t.Fatalf("read = %d with %v, want the read allowance", w.Code, w.Header())
+	}
+	if w := serve(http.MethodGet, "/users", "10.0.0.1:1234"); w.Header().Get("RateLimit-Limit") != "" {
+		t.Fatal("request matching no rule was limited")
+	}
+
+	limiter.err = errors.New("redis is down")
+	if w := serve(http.MethodPost, "/sign-in", "10.0.0.1:1234"); w.Code != http.StatusOK {
+		t.Fatalf("request while the limiter fails = %d, want it let through", w.Code)
+	}
+}
+
+func TestUnaryRateLimit(t *testing.T) {
+	limiter := &countingLimiter{calls: make(map[string]int)}
+	interceptor := UnaryRateLimit(limiter, "key", map[string]RateLimitRule{
+		"/bulletin.v1.UserService/SignIn": {Name: "auth", Limit: Limit{Requests: 2, Period: time.Minute}},
+	})
+	handler := func(ctx context.Context, req any) (any, error) { return "ok", nil }
+
+	call := func(method, ip string) error {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 1234}})
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return err
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := call("/bulletin.v1.UserService/SignIn", "10.0.0.1"); err != nil {
+			t.Fatalf("call %d = %v", i, err)
+		}
+	}
+	if err := call("/bulletin.v1.UserService/SignIn", "10.0.0.1"); apperror.KindOf(err) != apperror.KindTooManyRequests {
+		t.Fatalf("third call = %v, want too many requests", err)
+	}
+	if limiter.calls["ratelimit:auth:ip:10.0.0.1"] != 3 {
+		t.Fatalf("limiter keys = %v, want the HTTP auth allowance", limiter.calls)
+	}
+	if err := call("/bulletin.v1.UserService/SignIn", "10.0.0.2"); err != nil {
+		t.Fatalf("other client = %v, want its own allowance", err)
+	}
+	if err := call("/bulletin.v1.UserService/ListUsers", "10.0.0.1"); err != nil {
+		t.Fatalf("method without a rule = %v", err)
+	}
+
+	limiter.err = errors.New("redis is down")
+	if err := call("/bulletin.v1.UserService/SignIn", "10.0.0.1"); err != nil {
+		t.Fatalf("call while the limiter fails = %v, want it let through", err)
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	tests := map[string]Limit{
+		"10/m":  {Requests: 10, Period: time.Minute},
+		"5/30s": {Requests: 5, Period: 30 * time.Second},
+		"100/d": {Requests: 100, Period: 24 * time.Hour},
+		"off":   {},
+	}
+	for s, want := range tests {
+		got, err := ParseLimit(s)
+		if err != nil || got != want {
+			t.Errorf("ParseLimit(%q) = %v, %v, want %v", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "10", "x/m", "-1/m", "10/fortnight", "10/-1s"} {
+		if _, err := ParseLimit(s); err == nil {
+			t.Errorf("ParseLimit(%q) succeeded", s)
+		}
+	}
+}
+
+func TestCharge(t *testing.T) {
+	limiter := &countingLimiter{calls: make(map[string]int)}
+	rule := RateLimitRule{Name: "auth", Limit: Limit{Requests: 1, Period: time.Minute}}
+
+	var errs []error
+	handler := RateLimitCaller("key")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 2; i++ {
+			errs = append(errs, Charge(r.Context(), limiter, rule))
+		}
+	}))
+	r := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	if errs[0] != nil || !errors.Is(errs[1], errRateLimited) {
+		t.Fatalf("Charge = %v, want the second call rate limited", errs)
+	}
+	if err := Charge(context.Background(), limiter, rule); err != nil {
+		t.Fatalf("Charge without a caller = %v, want nil", err)
+	}
+}
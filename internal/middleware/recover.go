@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"bulletin-board/internal/apperror"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+)
+
+// Recover turns a panicking handler into a 500 problem response, or
+// only logs the panic if the handler already started its response.
+// http.ErrAbortHandler is passed on, since it is meant to abort.
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if err, ok := v.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(v)
+			}
+
+			slog.ErrorContext(r.Context(), "handler panicked",
+				slog.Any("panic", v),
+				slog.String("stack", string(debug.Stack())),
+			)
+			if !rec.wroteHeader {
+				apperror.Write(rec, r, fmt.Errorf("panic: %v", v))
+			}
+		}()
+		next.ServeHTTP(rec, r)
+	})
+}
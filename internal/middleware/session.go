@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"bulletin-board/pkg/postgresql"
+	"context"
+	"google.golang.org/grpc"
+	"net/http"
+)
+
+// DBSession gives each request its own read-your-writes session, so
+// reads that follow a write in the same request skip the replicas.
+func DBSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(postgresql.WithSession(r.Context())))
+	})
+}
+
+func UnaryDBSession(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	return handler(postgresql.WithSession(ctx), req)
+}
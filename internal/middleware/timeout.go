@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// TimeoutRule bounds the requests Match selects to Timeout.
+type TimeoutRule struct {
+	Match   func(r *http.Request) bool
+	Timeout time.Duration
+}
+
+// Timeout gives each request a context deadline from the first matching
+// rule. Requests no rule matches, or matching a rule with no timeout,
+// keep their context. Work that runs past the deadline fails with
+// context.DeadlineExceeded, which apperror reports as 503.
+func Timeout(rules ...TimeoutRule) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, rule := range rules {
+				if !rule.Match(r) {
+					continue
+				}
+				if rule.Timeout > 0 {
+					ctx, cancel := context.WithTimeout(r.Context(), rule.Timeout)
+					defer cancel()
+					r = r.WithContext(ctx)
+				}
+				break
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
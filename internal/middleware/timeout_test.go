@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"bulletin-board/internal/apperror"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	var (
+		deadline    time.Time
+		hasDeadline bool
+	)
+	handler := Timeout(
+		TimeoutRule{Match: Route(http.MethodGet, "/ads"), Timeout: 10 * time.Millisecond},
+		TimeoutRule{Match: Route(http.MethodGet, "/debug"), Timeout: 0},
+		TimeoutRule{Match: Route("", "/"), Timeout: time.Minute},
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, hasDeadline = r.Context().Deadline()
+		if r.URL.Path == "/ads/1" {
+			<-r.Context().Done()
+			apperror.Write(w, r, r.Context().Err())
+		}
+	}))
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	start := time.Now()
+	if w := serve(http.MethodGet, "/ads/1"); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("timed out request = %d, want 503", w.Code)
+	}
+	if !hasDeadline || deadline.Sub(start) > time.Second {
+		t.Fatalf("read deadline %v after the start, want the read rule", deadline.Sub(start))
+	}
+
+	serve(http.MethodPost, "/ads")
+	if !hasDeadline || deadline.Sub(start) < 59*time.Second {
+		t.Fatalf("write deadline %v after the start, want the write rule", deadline.Sub(start))
+	}
+
+	serve(http.MethodGet, "/debug/vars")
+	if hasDeadline {
+		t.Fatal("rule without a timeout set a deadline")
+	}
+}
@@ -0,0 +1,8 @@
+create table if not exists users (
+	id {{.ID}},
+	name text not null,
+	email text not null unique,
+	password text not null,
+	birthday {{.Date}} not null,
+	contact text not null default ''
+);
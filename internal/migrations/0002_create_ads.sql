@@ -0,0 +1,9 @@
+create table if not exists ads (
+	id {{.ID}},
+	title text not null,
+	description text not null default '',
+	price integer not null default 0 check (price >= 0),
+	user_id integer not null references users (id) on delete cascade
+);
+
+create index if not exists ads_user_id_idx on ads (user_id);
@@ -0,0 +1,7 @@
+alter table users add column version integer not null default 1;
+alter table users add column updated_at {{.Timestamp}} not null default '1970-01-01T00:00:00Z';
+update users set updated_at = {{.Now}};
+
+alter table ads add column version integer not null default 1;
+alter table ads add column updated_at {{.Timestamp}} not null default '1970-01-01T00:00:00Z';
+update ads set updated_at = {{.Now}};
@@ -0,0 +1,11 @@
+alter table users add column tier text not null default 'basic';
+
+alter table ads add column created_at {{.Timestamp}} not null default '1970-01-01T00:00:00Z';
+
+create table if not exists ad_creations (
+	id {{.ID}},
+	user_id integer not null references users (id) on delete cascade,
+	created_at {{.Timestamp}} not null
+);
+
+create index if not exists ad_creations_user_id_created_at_idx on ad_creations (user_id, created_at);
@@ -0,0 +1,122 @@
+// Package migrations holds the schema shared by the Postgres and SQLite
+// backends. Scripts are text/template files; the few types that differ
+// between the databases come from the Dialect.
+package migrations
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+type Dialect struct {
+	Name string
+	// ID is the definition of an auto-incrementing integer primary key.
+	ID string
+	// Date is the column type used for calendar dates.
+	Date string
+	// Timestamp is the column type used for instants, and Now the
+	// expression for the current one in that type.
+	Timestamp string
+	Now       string
+}
+
+var (
+	Postgres = Dialect{
+		Name:      "postgres",
+		ID:        "serial primary key",
+		Date:      "date",
+		Timestamp: "timestamptz",
+		Now:       "now()",
+	}
+	SQLite = Dialect{
+		Name:      "sqlite",
+		ID:        "integer primary key autoincrement",
+		Date:      "text",
+		Timestamp: "text",
+		Now:       "strftime('%Y-%m-%dT%H:%M:%fZ', 'now')",
+	}
+)
+
+// Target is a database that migrations can be applied to.
+type Target interface {
+	// Applied creates the version table if needed and lists the applied
+	// versions.
+	Applied(ctx context.Context) (map[string]bool, error)
+	// Apply runs script and records version in a single transaction.
+	Apply(ctx context.Context, version, script string) error
+}
+
+// Locker is implemented by targets that several processes may migrate
+// at once, such as a database shared by all instances of the service.
+type Locker interface {
+	// Lock blocks until no other process migrates the target and
+	// returns the function that lets them again.
+	Lock(ctx context.Context) (unlock func(), err error)
+}
+
+const versionTable = `
+	create table if not exists schema_migrations (
+		version text primary key
+	)`
+
+// Up applies every migration that target has not seen yet, in order.
+// Targets that are Lockers are locked for the duration.
+func Up(ctx context.Context, target Target, dialect Dialect) error {
+	if locker, ok := target.(Locker); ok {
+		unlock, err := locker.Lock(ctx)
+		if err != nil {
+			return fmt.Errorf("lock migrations: %w", err)
+		}
+		defer unlock()
+	}
+
+	applied, err := target.Applied(ctx)
+	if err != nil {
+		return fmt.Errorf("read applied migrations: %w", err)
+	}
+
+	names, err := fs.Glob(files, "*.sql")
+	if err != nil {
+		return err
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		version := strings.TrimSuffix(name, ".sql")
+		if applied[version] {
+			continue
+		}
+
+		script, err := render(name, dialect)
+		if err != nil {
+			return err
+		}
+		if err := target.Apply(ctx, version, script); err != nil {
+			return fmt.Errorf("apply migration %s: %w", version, err)
+		}
+		slog.InfoContext(ctx, "applied migration", slog.String("version", version), slog.String("dialect", dialect.Name))
+	}
+	return nil
+}
+
+func render(name string, dialect Dialect) (string, error) {
+	tpl, err := template.ParseFS(files, name)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, dialect); err != nil {
+		return "", fmt.Errorf("render migration %s: %w", name, err)
+	}
+	return buf.String(), nil
+}
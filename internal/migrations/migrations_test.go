@@ -0,0 +1,48 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// lockingTarget records the order in which Up uses it.
+type lockingTarget struct {
+	events  []string
+	lockErr error
+}
+
+func (t *lockingTarget) Lock(ctx context.Context) (func(), error) {
+	t.events = append(t.events, "lock")
+	if t.lockErr != nil {
+		return nil, t.lockErr
+	}
+	return func() { t.events = append(t.events, "unlock") }, nil
+}
+
+func (t *lockingTarget) Applied(ctx context.Context) (map[string]bool, error) {
+	t.events = append(t.events, "applied")
+	return map[string]bool{}, nil
+}
+
+func (t *lockingTarget) Apply(ctx context.Context, version, script string) error {
+	t.events = append(t.events, version)
+	return nil
+}
+
+func TestUpLocks(t *testing.T) {
+	target := &lockingTarget{}
+	if err := Up(context.Background(), target, Postgres); err != nil {
+		t.Fatal(err)
+	}
+	n := len(target.events)
+	if n < 4 || target.events[0] != "lock" || target.events[1] != "applied" || target.events[n-1] != "unlock" {
+		t.Fatalf("Up did %v, want every step between lock and unlock", target.events)
+	}
+
+	target = &lockingTarget{lockErr: errors.New("connection refused")}
+	if err := Up(context.Background(), target, Postgres); err == nil || !slices.Equal(target.events, []string{"lock"}) {
+		t.Fatalf("Up with a failing lock = %v after %v, want an error before reading migrations", err, target.events)
+	}
+}
@@ -0,0 +1,126 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"log/slog"
+)
+
+// pgLockID is the key of the advisory lock that keeps instances from
+// migrating the database at the same time.
+const pgLockID = 3_141_592_653
+
+type pgTarget struct {
+	client *pgxpool.Pool
+}
+
+func NewPostgresTarget(client *pgxpool.Pool) Target {
+	return pgTarget{client: client}
+}
+
+// Lock takes a session advisory lock, so it holds a connection of its
+// own until unlocked.
+func (t pgTarget) Lock(ctx context.Context) (func(), error) {
+	conn, err := t.client.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Exec(ctx, "select pg_advisory_lock($1)", pgLockID); err != nil {
+		conn.Release()
+		return nil, err
+	}
+
+	return func() {
+		ctx := context.WithoutCancel(ctx)
+		if _, err := conn.Exec(ctx, "select pg_advisory_unlock($1)", pgLockID); err != nil {
+			// Ending the session is the other way to release the lock.
+			slog.WarnContext(ctx, "error to unlock migrations", slog.Any("error", err))
+			conn.Hijack().Close(ctx)
+			return
+		}
+		conn.Release()
+	}, nil
+}
+
+func (t pgTarget) Applied(ctx context.Context) (map[string]bool, error) {
+	if _, err := t.client.Exec(ctx, versionTable); err != nil {
+		return nil, err
+	}
+	rows, err := t.client.Query(ctx, "select version from schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[string]bool{}
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
+
+func (t pgTarget) Apply(ctx context.Context, version, script string) error {
+	tx, err := t.client.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, "insert into schema_migrations (version) values ($1)", version); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+type sqlTarget struct {
+	db *sql.DB
+}
+
+func NewSQLiteTarget(db *sql.DB) Target {
+	return sqlTarget{db: db}
+}
+
+func (t sqlTarget) Applied(ctx context.Context) (map[string]bool, error) {
+	if _, err := t.db.ExecContext(ctx, versionTable); err != nil {
+		return nil, err
+	}
+	rows, err := t.db.QueryContext(ctx, "select version from schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[string]bool{}
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
+
+func (t sqlTarget) Apply(ctx context.Context, version, script string) error {
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "insert into schema_migrations (version) values (?)", version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
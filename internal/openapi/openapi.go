@@ -0,0 +1,30 @@
+package openapi
+
+import (
+	_ "embed"
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+//go:embed openapi.json
+var spec []byte
+
+//go:embed docs.html
+var docs []byte
+
+func Spec() []byte {
+	return spec
+}
+
+func NewRouter(r *mux.Router) {
+	r.HandleFunc("/openapi.json", serve("application/json", spec)).Methods("GET")
+	r.HandleFunc("/docs", serve("text/html; charset=utf-8", docs)).Methods("GET")
+}
+
+func serve(contentType string, body []byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(body)
+	}
+}
@@ -0,0 +1,45 @@
+package openapi_test
+
+import (
+	adApi "bulletin-board/internal/ad/transport/api"
+	"bulletin-board/internal/openapi"
+	userApi "bulletin-board/internal/user/transport/api"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"strings"
+	"testing"
+)
+
+func TestSpecCoversRoutes(t *testing.T) {
+	var doc struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(openapi.Spec(), &doc); err != nil {
+		t.Fatalf("parse spec: %v", err)
+	}
+
+	r := mux.NewRouter()
+	adApi.Handler{}.NewRouter(r)
+	userApi.Handler{}.NewRouter(r)
+
+	err := r.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+
+		for _, method := range methods {
+			if _, ok := doc.Paths[path][strings.ToLower(method)]; !ok {
+				t.Errorf("route %s %s is missing from openapi.json", method, path)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,70 @@
+// Package patch applies JSON Merge Patch (RFC 7396) and JSON Patch
+// (RFC 6902) documents to request DTOs.
+package patch
+
+import (
+	"bulletin-board/internal/apperror"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/evanphx/json-patch/v5"
+	"mime"
+	"net/http"
+	"reflect"
+)
+
+const (
+	MergePatch = "application/merge-patch+json"
+	JSONPatch  = "application/json-patch+json"
+)
+
+var (
+	errUnsupported = apperror.UnsupportedMediaType("patch must be " + MergePatch + " or " + JSONPatch)
+	errTestFailed  = apperror.Conflict("patch test operation failed")
+)
+
+// MediaType returns the patch format named by the request's Content-Type.
+func MediaType(r *http.Request) (string, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (mediaType != MergePatch && mediaType != JSONPatch) {
+		return "", errUnsupported
+	}
+	return mediaType, nil
+}
+
+// Apply patches the JSON encoding of doc, a pointer to a struct, and
+// decodes the result back into it. Members the patch removes come back
+// as zero values; members doc does not have are rejected.
+func Apply(mediaType string, body []byte, doc any) error {
+	original, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	var patched []byte
+	switch mediaType {
+	case MergePatch:
+		patched, err = jsonpatch.MergePatch(original, body)
+	case JSONPatch:
+		var ops jsonpatch.Patch
+		if ops, err = jsonpatch.DecodePatch(body); err == nil {
+			patched, err = ops.Apply(original)
+		}
+	default:
+		return errUnsupported
+	}
+	if errors.Is(err, jsonpatch.ErrTestFailed) {
+		return errTestFailed
+	}
+	if err != nil {
+		return apperror.BadRequest("invalid patch: " + err.Error())
+	}
+
+	reflect.ValueOf(doc).Elem().SetZero()
+	dec := json.NewDecoder(bytes.NewReader(patched))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(doc); err != nil {
+		return apperror.BadRequest("invalid patch result: " + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package patch
+
+import (
+	"bulletin-board/internal/apperror"
+	"net/http/httptest"
+	"testing"
+)
+
+type doc struct {
+	Title string `json:"title"`
+	Price int    `json:"price"`
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name      string
+		mediaType string
+		body      string
+		want      doc
+		kind      apperror.Kind
+	}{
+		{"merge one member", MergePatch, `{"price": 5}`, doc{Title: "Bike", Price: 5}, -1},
+		{"merge null resets", MergePatch, `{"title": null}`, doc{Price: 10}, -1},
+		{"merge unknown member", MergePatch, `{"user_id": 2}`, doc{}, apperror.KindBadRequest},
+		{"merge wrong type", MergePatch, `{"price": "five"}`, doc{}, apperror.KindBadRequest},
+		{"json patch replace", JSONPatch, `[{"op": "replace", "path": "/title", "value": "Boat"}]`, doc{Title: "Boat", Price: 10}, -1},
+		{"json patch test passes", JSONPatch, `[{"op": "test", "path": "/price", "value": 10}, {"op": "replace", "path": "/price", "value": 1}]`, doc{Title: "Bike", Price: 1}, -1},
+		{"json patch test fails", JSONPatch, `[{"op": "test", "path": "/price", "value": 11}]`, doc{}, apperror.KindConflict},
+		{"json patch malformed", JSONPatch, `{"op": "replace"}`, doc{}, apperror.KindBadRequest},
+		{"json patch missing path", JSONPatch, `[{"op": "remove", "path": "/owner"}]`, doc{}, apperror.KindBadRequest},
+		{"unsupported", "application/json", `{}`, doc{}, apperror.KindUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := doc{Title: "Bike", Price: 10}
+			err := Apply(tt.mediaType, []byte(tt.body), &d)
+			if tt.kind >= 0 {
+				if err == nil || apperror.KindOf(err) != tt.kind {
+					t.Fatalf("Apply error = %v, want kind %d", err, tt.kind)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			if d != tt.want {
+				t.Fatalf("got %+v, want %+v", d, tt.want)
+			}
+		})
+	}
+}
+
+func TestMediaType(t *testing.T) {
+	for contentType, ok := range map[string]bool{
+		"application/merge-patch+json":               true,
+		"application/json-patch+json; charset=utf-8": true,
+		"application/json":                           false,
+		"":                                           false,
+	} {
+		r := httptest.NewRequest("PATCH", "/ads/1", nil)
+		r.Header.Set("Content-Type", contentType)
+		_, err := MediaType(r)
+		if (err == nil) != ok {
+			t.Errorf("MediaType(%q) error = %v", contentType, err)
+		}
+	}
+}
@@ -1,25 +1,229 @@
 package redisdb
 
 import (
+	"bulletin-board/pkg/breaker"
+	"bulletin-board/pkg/cache"
+	"bulletin-board/pkg/retry"
 	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
-	"log"
+	"log/slog"
+	"strings"
+	"time"
 )
 
+const reconnectInterval = 5 * time.Second
+
+var (
+	metricDegraded     = expvar.NewInt("redis_degraded")
+	metricBreakerTrips = expvar.NewInt("redis_breaker_trips_total")
+	metricRejected     = expvar.NewInt("redis_rejected_calls_total")
+	metricErrors       = expvar.NewInt("redis_errors_total")
+)
+
+// RedisClient wraps the Redis connection in a circuit breaker. While
+// the breaker is open calls fail fast with breaker.ErrOpen and callers
+// are expected to fall back to the primary store.
 type RedisClient struct {
-	Rds *redis.Client
+	Rds     *redis.Client
+	breaker *breaker.Breaker
 }
 
-func New(ctx context.Context) *RedisClient {
+// New connects to Redis. If Redis cannot be reached the client is
+// returned in degraded mode and reconnects in the background; only
+// configuration errors are returned.
+func New(ctx context.Context) (*RedisClient, error) {
 	client := redis.NewClient(&redis.Options{
 		Addr:     "localhost:6379",
 		Password: "",
 		DB:       0,
 	})
 
-	_, err := client.Ping(ctx).Result()
+	if err := redisotel.InstrumentTracing(client); err != nil {
+		return nil, fmt.Errorf("instrument redis tracing: %w", err)
+	}
+
+	c := &RedisClient{
+		Rds: client,
+		breaker: breaker.New(breaker.Config{
+			FailureThreshold: 5,
+			OpenTimeout:      reconnectInterval,
+			IsFailure:        isFailure,
+			OnStateChange:    onStateChange,
+		}),
+	}
+
+	policy := retry.Default()
+	policy.MaxElapsedTime = 5 * time.Second
+	err := retry.Do(ctx, policy, func(ctx context.Context) error {
+		err := c.ping(ctx)
+		if isAuthError(err) {
+			return retry.Permanent(err)
+		}
+		return err
+	})
+	if isAuthError(err) {
+		_ = client.Close()
+		return nil, fmt.Errorf("connect to redis: %w", err)
+	}
 	if err != nil {
-		log.Fatal("Ошибка при подключении к Redis")
+		slog.WarnContext(ctx, "redis is unavailable, starting without cache", slog.Any("error", err))
+		c.breaker.Trip()
+	}
+
+	go c.reconnect(ctx)
+	return c, nil
+}
+
+// Get, Set and Delete implement cache.Cache.
+func (c RedisClient) Get(ctx context.Context, key string) ([]byte, error) {
+	var val []byte
+	err := c.do(func() (err error) {
+		val, err = c.Rds.Get(ctx, key).Bytes()
+		return err
+	})
+	if errors.Is(err, redis.Nil) {
+		return nil, cache.ErrMiss
 	}
-	return &RedisClient{Rds: client}
+	return val, err
+}
+
+func (c RedisClient) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.do(func() error {
+		return c.Rds.Set(ctx, key, value, ttl).Err()
+	})
+}
+
+// SetWithTags stores the entry and adds its key to a Redis set per tag.
+// Tag sets expire with their newest member.
+func (c RedisClient) SetWithTags(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error {
+	return c.do(func() error {
+		_, err := c.Rds.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, value, ttl)
+			for _, tag := range tags {
+				pipe.SAdd(ctx, tagKey(tag), key)
+				if ttl > 0 {
+					pipe.Expire(ctx, tagKey(tag), ttl)
+				}
+			}
+			return nil
+		})
+		return err
+	})
+}
+
+// invalidateTags deletes the members of every tag set and the sets
+// themselves in one atomic step, so no key can be tagged in between.
+var invalidateTags = redis.NewScript(`
+local removed = {}
+for _, tag in ipairs(KEYS) do
+	for _, key in ipairs(redis.call('SMEMBERS', tag)) do
+		redis.call('DEL', key)
+		table.insert(removed, key)
+	end
+	redis.call('DEL', tag)
+end
+return removed
+`)
+
+func (c RedisClient) InvalidateTags(ctx context.Context, tags ...string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	keys := make([]string, len(tags))
+	for i, tag := range tags {
+		keys[i] = tagKey(tag)
+	}
+
+	var removed []string
+	err := c.do(func() (err error) {
+		removed, err = invalidateTags.Run(ctx, c.Rds, keys).StringSlice()
+		return err
+	})
+	return removed, err
+}
+
+func tagKey(tag string) string {
+	return "tag:" + tag
+}
+
+func (c RedisClient) Delete(ctx context.Context, keys ...string) error {
+	return c.do(func() error {
+		return c.Rds.Del(ctx, keys...).Err()
+	})
+}
+
+// Degraded reports whether cache calls are currently short-circuited.
+func (c RedisClient) Degraded() bool {
+	return c.breaker.State() != breaker.Closed
+}
+
+func (c RedisClient) do(fn func() error) error {
+	err := c.breaker.Do(fn)
+	switch {
+	case errors.Is(err, breaker.ErrOpen):
+		metricRejected.Add(1)
+	case err != nil && !errors.Is(err, redis.Nil):
+		metricErrors.Add(1)
+	}
+	return err
+}
+
+func (c RedisClient) ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	return c.Rds.Ping(ctx).Err()
+}
+
+// reconnect pings Redis while the breaker is open so that the cache
+// comes back without waiting for a request to probe it.
+func (c RedisClient) reconnect(ctx context.Context) {
+	ticker := time.NewTicker(reconnectInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if c.breaker.State() == breaker.Open {
+			if err := c.ping(ctx); err == nil {
+				c.breaker.Report(nil)
+			}
+		}
+	}
+}
+
+func onStateChange(from, to breaker.State) {
+	switch to {
+	case breaker.Open:
+		metricBreakerTrips.Add(1)
+		metricDegraded.Set(1)
+		if from == breaker.Closed {
+			slog.Warn("redis circuit breaker opened, serving without cache")
+		}
+	case breaker.Closed:
+		metricDegraded.Set(0)
+		slog.Info("redis circuit breaker closed, cache is back")
+	}
+}
+
+func isAuthError(err error) bool {
+	var rdsErr redis.Error
+	if err == nil || !errors.As(err, &rdsErr) {
+		return false
+	}
+	msg := rdsErr.Error()
+	return strings.HasPrefix(msg, "WRONGPASS") || strings.HasPrefix(msg, "NOAUTH")
+}
+
+// isFailure tells which errors count against the breaker. Cache misses
+// and requests the caller cancelled or let time out say nothing about
+// the health of Redis.
+func isFailure(err error) bool {
+	return !errors.Is(err, redis.Nil) && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
 }
@@ -0,0 +1,24 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"testing"
+)
+
+func TestIsFailure(t *testing.T) {
+	tests := map[error]bool{
+		errors.New("connection refused"): true,
+		redis.Nil:                        false,
+		context.Canceled:                 false,
+		fmt.Errorf("get: %w", context.DeadlineExceeded):       false,
+		fmt.Errorf("pipeline: %w", errors.New("i/o timeout")): true,
+	}
+	for err, want := range tests {
+		if got := isFailure(err); got != want {
+			t.Errorf("isFailure(%v) = %v, want %v", err, got, want)
+		}
+	}
+}
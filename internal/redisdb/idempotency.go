@@ -0,0 +1,70 @@
+package redisdb
+
+import (
+	"bulletin-board/internal/middleware"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+type idempotencyStore struct {
+	client *RedisClient
+}
+
+// NewIdempotencyStore keeps idempotent responses as JSON strings that
+// expire with the idempotency window, or the lock window while pending.
+func NewIdempotencyStore(client *RedisClient) middleware.IdempotencyStore {
+	return idempotencyStore{client: client}
+}
+
+func (s idempotencyStore) Reserve(ctx context.Context, key string, pending middleware.IdempotentResponse, ttl time.Duration) (middleware.IdempotentResponse, bool, error) {
+	value, err := json.Marshal(pending)
+	if err != nil {
+		return middleware.IdempotentResponse{}, false, err
+	}
+
+	// The stored entry can expire between SETNX and GET; try again then.
+	for range 2 {
+		var (
+			reserved bool
+			stored   []byte
+		)
+		err := s.client.do(func() (err error) {
+			reserved, err = s.client.Rds.SetNX(ctx, key, value, ttl).Result()
+			if err != nil || reserved {
+				return err
+			}
+			stored, err = s.client.Rds.Get(ctx, key).Bytes()
+			return err
+		})
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil || reserved {
+			return middleware.IdempotentResponse{}, reserved, err
+		}
+
+		var resp middleware.IdempotentResponse
+		if err := json.Unmarshal(stored, &resp); err != nil {
+			return middleware.IdempotentResponse{}, false, err
+		}
+		return resp, false, nil
+	}
+	return middleware.IdempotentResponse{}, false, errors.New("idempotency key expired while being read")
+}
+
+func (s idempotencyStore) Save(ctx context.Context, key string, resp middleware.IdempotentResponse, ttl time.Duration) error {
+	value, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	return s.client.do(func() error {
+		return s.client.Rds.Set(ctx, key, value, ttl).Err()
+	})
+}
+
+func (s idempotencyStore) Release(ctx context.Context, key string) error {
+	return s.client.Delete(ctx, key)
+}
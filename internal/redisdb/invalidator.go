@@ -0,0 +1,67 @@
+package redisdb
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/redis/go-redis/v9"
+	"log/slog"
+	"time"
+)
+
+// Invalidator implements cache.Invalidator over a Redis pub/sub
+// channel. Messages are JSON arrays of keys.
+type Invalidator struct {
+	client  RedisClient
+	channel string
+}
+
+func NewInvalidator(client *RedisClient, channel string) *Invalidator {
+	return &Invalidator{client: *client, channel: channel}
+}
+
+func (i *Invalidator) Publish(ctx context.Context, keys []string) error {
+	payload, err := json.Marshal(keys)
+	if err != nil {
+		return err
+	}
+	return i.client.do(func() error {
+		return i.client.Rds.Publish(ctx, i.channel, payload).Err()
+	})
+}
+
+func (i *Invalidator) Listen(ctx context.Context, onKeys func(keys []string), onReset func()) {
+	ps := i.client.Rds.Subscribe(ctx, i.channel)
+	defer ps.Close()
+
+	for {
+		msg, err := ps.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			// The next Receive reconnects and resubscribes; a
+			// *redis.Subscription then signals that messages may have
+			// been lost in between.
+			slog.DebugContext(ctx, "redis invalidation channel failed", slog.Any("error", err))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		switch m := msg.(type) {
+		case *redis.Subscription:
+			onReset()
+		case *redis.Message:
+			var keys []string
+			if err := json.Unmarshal([]byte(m.Payload), &keys); err != nil {
+				slog.WarnContext(ctx, "invalid cache invalidation message", slog.Any("error", err))
+				onReset()
+				continue
+			}
+			onKeys(keys)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package redisdb
+
+import (
+	"bulletin-board/internal/middleware"
+	"context"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// gcra implements the generic cell rate algorithm: each key holds the
+// theoretical arrival time (TAT) of the next request, in microseconds
+// of the Redis clock, so all app instances share one view of time. A
+// request is allowed while TAT lies at most one burst ahead of now.
+//
+// It returns allowed, remaining, retry after and reset, the latter two
+// in microseconds.
+var gcra = redis.NewScript(`
+local clock = redis.call('TIME')
+local now = tonumber(clock[1]) * 1000000 + tonumber(clock[2])
+local interval = tonumber(ARGV[1])
+local tolerance = interval * tonumber(ARGV[2])
+
+local tat = tonumber(redis.call('GET', KEYS[1])) or now
+if tat < now then
+	tat = now
+end
+
+local new_tat = tat + interval
+local allow_at = new_tat - tolerance
+if allow_at > now then
+	return {0, 0, allow_at - now, tat - now}
+end
+
+-- Lua prints numbers with 14 significant digits, too few for TAT.
+redis.call('SET', KEYS[1], string.format('%.0f', new_tat), 'PX', math.ceil((new_tat - now) / 1000))
+return {1, math.floor((now - allow_at) / interval), 0, new_tat - now}
+`)
+
+type rateLimiter struct {
+	client *RedisClient
+}
+
+func NewRateLimiter(client *RedisClient) middleware.RateLimiter {
+	return rateLimiter{client: client}
+}
+
+func (l rateLimiter) Allow(ctx context.Context, key string, limit middleware.Limit) (middleware.RateLimitResult, error) {
+	interval := limit.Period.Microseconds() / int64(limit.Requests)
+
+	var res []int64
+	err := l.client.do(func() (err error) {
+		res, err = gcra.Run(ctx, l.client.Rds, []string{key}, max(interval, 1), limit.Requests).Int64Slice()
+		return err
+	})
+	if err != nil {
+		return middleware.RateLimitResult{}, err
+	}
+	return middleware.RateLimitResult{
+		Allowed:    res[0] == 1,
+		Remaining:  int(res[1]),
+		RetryAfter: time.Duration(res[2]) * time.Microsecond,
+		Reset:      time.Duration(res[3]) * time.Microsecond,
+	}, nil
+}
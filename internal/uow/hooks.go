@@ -0,0 +1,56 @@
+package uow
+
+import "context"
+
+type hooksKey struct{}
+
+type hooks struct {
+	fns []func(ctx context.Context)
+}
+
+// WithAfterCommit wraps u so that functions registered with AfterCommit
+// inside Do run once the unit of work has succeeded. Hooks registered
+// by an attempt that is retried or rolled back are discarded.
+func WithAfterCommit(u UnitOfWork) UnitOfWork {
+	return afterCommit{next: u}
+}
+
+type afterCommit struct {
+	next UnitOfWork
+}
+
+func (a afterCommit) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(hooksKey{}).(*hooks); ok {
+		return a.next.Do(ctx, fn)
+	}
+
+	h := &hooks{}
+	err := a.next.Do(ctx, func(ctx context.Context) error {
+		h.fns = nil
+		return fn(context.WithValue(ctx, hooksKey{}, h))
+	})
+	if err != nil {
+		return err
+	}
+	for _, f := range h.fns {
+		f(ctx)
+	}
+	return nil
+}
+
+// AfterCommit runs fn after the enclosing unit of work commits, or
+// immediately when ctx is not part of one.
+func AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	if h, ok := ctx.Value(hooksKey{}).(*hooks); ok {
+		h.fns = append(h.fns, fn)
+		return
+	}
+	fn(ctx)
+}
+
+// InProgress reports whether ctx belongs to a unit of work created by
+// WithAfterCommit.
+func InProgress(ctx context.Context) bool {
+	_, ok := ctx.Value(hooksKey{}).(*hooks)
+	return ok
+}
@@ -0,0 +1,21 @@
+package uow
+
+import "context"
+
+// UnitOfWork runs fn so that every repository call made with the
+// context passed to fn commits or rolls back together.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type noop struct{}
+
+// NoOp returns a UnitOfWork for backends without transactions. It runs
+// fn directly, so the operations are not atomic.
+func NoOp() UnitOfWork {
+	return noop{}
+}
+
+func (noop) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
@@ -0,0 +1,115 @@
+package cached
+
+import (
+	adCached "bulletin-board/internal/ad/repository/cached"
+	"bulletin-board/internal/uow"
+	"bulletin-board/internal/user"
+	"bulletin-board/pkg/breaker"
+	"bulletin-board/pkg/cache"
+	"bulletin-board/pkg/postgresql"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// repository caches GetByID the same way as the ad decorator. Lookups
+// by email are never cached since they carry the password hash.
+type repository struct {
+	next  user.Repository
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+func (r repository) GetAll(ctx context.Context) ([]user.User, error) {
+	return r.next.GetAll(ctx)
+}
+
+func (r repository) GetByID(ctx context.Context, id int) (user.User, error) {
+	if uow.InProgress(ctx) {
+		return r.next.GetByID(ctx, id)
+	}
+
+	key := userKey(id)
+	if data, err := r.cache.Get(ctx, key); err == nil {
+		var cached user.User
+		if err := json.Unmarshal(data, &cached); err == nil {
+			return cached, nil
+		}
+		logError(ctx, "cached user is corrupt", key, err)
+	} else if !errors.Is(err, cache.ErrMiss) {
+		logError(ctx, "cache get failed", key, err)
+	}
+
+	// Cached users must not carry a replica's lag.
+	found, err := r.next.GetByID(postgresql.WithPrimary(ctx), id)
+	if err != nil {
+		return user.User{}, err
+	}
+
+	found.Password = ""
+	data, err := json.Marshal(found)
+	if err != nil {
+		return found, nil
+	}
+	if err := r.cache.Set(ctx, key, data, r.ttl); err != nil {
+		logError(ctx, "cache set failed", key, err)
+	}
+	return found, nil
+}
+
+func (r repository) GetByIDs(ctx context.Context, ids []int) ([]user.User, error) {
+	return r.next.GetByIDs(ctx, ids)
+}
+
+func (r repository) GetByEmail(ctx context.Context, email string) (user.User, error) {
+	return r.next.GetByEmail(ctx, email)
+}
+
+func (r repository) Create(ctx context.Context, newUser user.User) (user.User, error) {
+	return r.next.Create(ctx, newUser)
+}
+
+func (r repository) Update(ctx context.Context, usr user.User, id int) (user.User, error) {
+	updated, err := r.next.Update(ctx, usr, id)
+	if err != nil {
+		return user.User{}, err
+	}
+	r.invalidate(ctx, id)
+	return updated, nil
+}
+
+func (r repository) Delete(ctx context.Context, id int) error {
+	if err := r.next.Delete(ctx, id); err != nil {
+		return err
+	}
+	r.invalidate(ctx, id)
+	adCached.InvalidateListings(ctx, r.cache, adCached.AllAdsTag, adCached.UserAdsTag(id))
+	return nil
+}
+
+func (r repository) invalidate(ctx context.Context, id int) {
+	uow.AfterCommit(ctx, func(ctx context.Context) {
+		key := userKey(id)
+		if err := r.cache.Delete(ctx, key); err != nil {
+			logError(ctx, "cache delete failed", key, err)
+		}
+	})
+}
+
+func userKey(id int) string {
+	return fmt.Sprintf("user:%d", id)
+}
+
+func logError(ctx context.Context, msg, key string, err error) {
+	if errors.Is(err, breaker.ErrOpen) {
+		return
+	}
+	slog.WarnContext(ctx, msg, slog.String("key", key), slog.Any("error", err))
+}
+
+func NewRepository(next user.Repository, c cache.Cache, ttl time.Duration) user.Repository {
+	return repository{next: next, cache: c, ttl: ttl}
+}
@@ -0,0 +1,47 @@
+package cached_test
+
+import (
+	"bulletin-board/internal/ad"
+	adCached "bulletin-board/internal/ad/repository/cached"
+	"bulletin-board/internal/memstore"
+	"bulletin-board/internal/user"
+	"bulletin-board/internal/user/cached"
+	"bulletin-board/internal/user/repotest"
+	"bulletin-board/pkg/cache"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRepository(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) (user.Repository, ad.Repository) {
+		db := memstore.New()
+		return cached.NewRepository(db.UserRepository(), cache.NewLRU(100), time.Minute), db.AdRepository()
+	})
+}
+
+func TestDeleteInvalidatesUserAdsListing(t *testing.T) {
+	ctx := context.Background()
+	db := memstore.New()
+	c := cache.NewLRU(100)
+	users := cached.NewRepository(db.UserRepository(), c, time.Minute)
+	ads := adCached.NewRepository(db.AdRepository(), c, time.Minute)
+
+	owner, err := users.Create(ctx, user.User{Name: "Owner", Email: "owner@example.com", Birthday: time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ads.Create(ctx, ad.Ad{Title: "Bike", Price: 100, UserID: owner.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := ads.GetByUserIDs(ctx, []int{owner.ID}); len(list) != 1 {
+		t.Fatalf("GetByUserIDs returned %d ads, want 1", len(list))
+	}
+
+	if err := users.Delete(ctx, owner.ID); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := ads.GetByUserIDs(ctx, []int{owner.ID}); len(list) != 0 {
+		t.Fatalf("GetByUserIDs after deleting the user = %+v, want empty", list)
+	}
+}
@@ -1,40 +1,53 @@
 package dto
 
 import (
+	"bulletin-board/internal/apperror"
 	"bulletin-board/internal/user"
+	"bulletin-board/internal/validation"
 	"time"
 )
 
 type ResponseUser struct {
-	ID       int    `json:"id"`
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Birthday  string    `json:"birthday"`
+	Contact   string    `json:"contact"`
+	Version   int       `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PatchUser is the part of a user that PATCH requests may change.
+type PatchUser struct {
 	Name     string `json:"name"`
-	Email    string `json:"email"`
 	Birthday string `json:"birthday"`
 	Contact  string `json:"contact"`
 }
 
 type RequestUser struct {
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
-	Birthday string `json:"birthday"`
-	Contact  string `json:"contact"`
+	Name     string `json:"name" validate:"required,min=2,max=50"`
+	Email    string `json:"email" validate:"required,email,max=254"`
+	Password string `json:"password" validate:"required,password=8,max=72"`
+	Birthday string `json:"birthday" validate:"required,date,minage=14,maxage=120"`
+	Contact  string `json:"contact" validate:"contact,max=100"`
 }
 
 func ToDto(user user.User) ResponseUser {
 	return ResponseUser{
-		ID:       user.ID,
-		Name:     user.Name,
-		Email:    user.Email,
-		Birthday: user.Birthday.Format("2006-01-02"),
-		Contact:  user.Contact,
+		ID:        user.ID,
+		Name:      user.Name,
+		Email:     user.Email,
+		Birthday:  user.Birthday.Format(validation.DateLayout),
+		Contact:   user.Contact,
+		Version:   user.Version,
+		UpdatedAt: user.UpdatedAt,
 	}
 }
 
-func ToUser(requestUser RequestUser) user.User {
-	birthday, err := time.Parse("2006-01-02", requestUser.Birthday)
+func ToUser(requestUser RequestUser) (user.User, error) {
+	birthday, err := time.Parse(validation.DateLayout, requestUser.Birthday)
 	if err != nil {
-		return user.User{}
+		return user.User{}, apperror.Validation("validation failed", apperror.FieldError{Field: "birthday", Message: "must be a date in YYYY-MM-DD format"})
 	}
 	return user.User{
 		Name:     requestUser.Name,
@@ -42,5 +55,13 @@ func ToUser(requestUser RequestUser) user.User {
 		Password: requestUser.Password,
 		Birthday: birthday,
 		Contact:  requestUser.Contact,
+	}, nil
+}
+
+func ToPatch(user user.User) PatchUser {
+	return PatchUser{
+		Name:     user.Name,
+		Birthday: user.Birthday.Format(validation.DateLayout),
+		Contact:  user.Contact,
 	}
 }
@@ -1,13 +1,15 @@
 package pgstore
 
 import (
-	"bulletin-board/internal/ad"
+	"bulletin-board/internal/apperror"
 	"bulletin-board/internal/user"
 	"bulletin-board/pkg/postgresql"
 	"context"
 	"errors"
 	"fmt"
 	"github.com/jackc/pgx/v5/pgconn"
+	"log/slog"
+	"time"
 )
 
 type repository struct {
@@ -16,9 +18,10 @@ type repository struct {
 
 func (r repository) GetAll(ctx context.Context) ([]user.User, error) {
 	q := `
-		select id, name, email, birthday, contact
-		from users`
-	rows, err := r.client.Query(ctx, q)
+		select id, name, email, birthday, contact, tier, version, updated_at
+		from users
+		order by id`
+	rows, err := r.conn(ctx).Query(ctx, q)
 	if err != nil {
 		return nil, err
 	}
@@ -28,7 +31,7 @@ func (r repository) GetAll(ctx context.Context) ([]user.User, error) {
 
 	for rows.Next() {
 		var user user.User
-		if err = rows.Scan(&user.ID, &user.Name, &user.Email, &user.Birthday, &user.Contact); err != nil {
+		if err = rows.Scan(&user.ID, &user.Name, &user.Email, &user.Birthday, &user.Contact, &user.Tier, &user.Version, &user.UpdatedAt); err != nil {
 			return nil, err
 		}
 		users = append(users, user)
@@ -43,77 +46,87 @@ func (r repository) GetAll(ctx context.Context) ([]user.User, error) {
 
 func (r repository) GetByID(ctx context.Context, id int) (user.User, error) {
 	q := `
-		select id, name, email, birthday, contact
+		select id, name, email, birthday, contact, tier, version, updated_at
 		from users
 		where id = $1`
 	var usr user.User
-	err := r.client.QueryRow(ctx, q, id).Scan(&usr.ID, &usr.Name, &usr.Email, &usr.Birthday, &usr.Contact)
+	err := r.conn(ctx).QueryRow(ctx, q, id).Scan(&usr.ID, &usr.Name, &usr.Email, &usr.Birthday, &usr.Contact, &usr.Tier, &usr.Version, &usr.UpdatedAt)
 	if err != nil {
+		if postgresql.IsNoRows(err) {
+			return user.User{}, user.ErrUserNotFound
+		}
 		return user.User{}, err
 	}
 	return usr, nil
 }
 
-func (r repository) GetByEmail(ctx context.Context, email string) (user.User, error) {
+func (r repository) GetByIDs(ctx context.Context, ids []int) ([]user.User, error) {
 	q := `
-		select id, name, email, password, birthday, contact
+		select id, name, email, birthday, contact, tier, version, updated_at
 		from users
-		where email = $1`
-	var usr user.User
-	err := r.client.QueryRow(ctx, q, email).Scan(&usr.ID, &usr.Name, &usr.Email, &usr.Password, &usr.Birthday, &usr.Contact)
-	if err != nil {
-		return user.User{}, err
-	}
-	return usr, nil
-}
-
-func (r repository) GetUsersAds(ctx context.Context, userId int) ([]ad.Ad, error) {
-	q := `
-		select id, title, description, price, user_id
-		from ads
-		where user_id = $1`
-
-	ads := make([]ad.Ad, 0)
-
-	rows, err := r.client.Query(ctx, q, userId)
+		where id = any($1)
+		order by id`
+	rows, err := r.conn(ctx).Query(ctx, q, ids)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	for rows.Next() {
-		var ad ad.Ad
-		err = rows.Scan(&ad.ID, &ad.Title, &ad.Description, &ad.Price, &ad.UserID)
+	users := make([]user.User, 0, len(ids))
 
-		if err != nil {
+	for rows.Next() {
+		var usr user.User
+		if err = rows.Scan(&usr.ID, &usr.Name, &usr.Email, &usr.Birthday, &usr.Contact, &usr.Tier, &usr.Version, &usr.UpdatedAt); err != nil {
 			return nil, err
 		}
-		ads = append(ads, ad)
+		users = append(users, usr)
 	}
 
-	err = rows.Err()
-	if err != nil {
+	if err = rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return ads, nil
+	return users, nil
+}
+
+func (r repository) GetByEmail(ctx context.Context, email string) (user.User, error) {
+	q := `
+		select id, name, email, password, birthday, contact, tier, version, updated_at
+		from users
+		where email = $1`
+	var usr user.User
+	err := r.conn(ctx).QueryRow(ctx, q, email).Scan(&usr.ID, &usr.Name, &usr.Email, &usr.Password, &usr.Birthday, &usr.Contact, &usr.Tier, &usr.Version, &usr.UpdatedAt)
+	if err != nil {
+		if postgresql.IsNoRows(err) {
+			return user.User{}, user.ErrUserNotFound
+		}
+		return user.User{}, err
+	}
+	return usr, nil
 }
 
 func (r repository) Create(ctx context.Context, newUser user.User) (user.User, error) {
 	q := `
-		insert into users (name, email, password, birthday, contact) 
-		values ($1, $2, $3, $4, $5)
-		returning id, name, email, birthday, contact`
+		insert into users (name, email, password, birthday, contact, version, updated_at) 
+		values ($1, $2, $3, $4, $5, 1, $6)
+		returning id, name, email, birthday, contact, tier, version, updated_at`
 
-	err := r.client.QueryRow(ctx, q, newUser.Name, newUser.Email, newUser.Password, newUser.Birthday, newUser.Contact).
-		Scan(&newUser.ID, &newUser.Name, &newUser.Email, &newUser.Birthday, &newUser.Contact)
+	err := r.conn(ctx).QueryRow(ctx, q, newUser.Name, newUser.Email, newUser.Password, newUser.Birthday, newUser.Contact, now()).
+		Scan(&newUser.ID, &newUser.Name, &newUser.Email, &newUser.Birthday, &newUser.Contact, &newUser.Tier, &newUser.Version, &newUser.UpdatedAt)
 
 	if err != nil {
+		if postgresql.IsUniqueViolation(err) {
+			return user.User{}, apperror.Wrap(user.ErrEmailTaken, err)
+		}
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
-			newErr := fmt.Errorf("SQL error: %s, Detail: %s, Where: %s, Code: %s, SQL State: %s", pgErr.Message, pgErr.Detail, pgErr.Where, pgErr.Code, pgErr.SQLState())
-			fmt.Println(newErr)
-			return user.User{}, newErr
+			slog.ErrorContext(ctx, "sql error",
+				slog.String("message", pgErr.Message),
+				slog.String("detail", pgErr.Detail),
+				slog.String("where", pgErr.Where),
+				slog.String("code", pgErr.Code),
+			)
+			return user.User{}, fmt.Errorf("create: %w", err)
 		}
 		return user.User{}, err
 	}
@@ -126,15 +139,20 @@ func (r repository) Update(ctx context.Context, newUser user.User, id int) (user
 		set 
 		    name = $1,
 		    birthday = $2,
-		    contact = $3
-		where id = $4
-		returning id, name, birthday, contact`
+		    contact = $3,
+		    version = version + 1,
+		    updated_at = $4
+		where id = $5
+		returning id, name, email, birthday, contact, tier, version, updated_at`
 
-	err := r.client.QueryRow(ctx, q, newUser.Name, newUser.Birthday, newUser.Contact, id).
-		Scan(&newUser.ID, &newUser.Name, &newUser.Birthday, &newUser.Contact)
+	err := r.conn(ctx).QueryRow(ctx, q, newUser.Name, newUser.Birthday, newUser.Contact, now(), id).
+		Scan(&newUser.ID, &newUser.Name, &newUser.Email, &newUser.Birthday, &newUser.Contact, &newUser.Tier, &newUser.Version, &newUser.UpdatedAt)
 
 	if err != nil {
-		return user.User{}, nil
+		if postgresql.IsNoRows(err) {
+			return user.User{}, user.ErrUserNotFound
+		}
+		return user.User{}, err
 	}
 
 	return newUser, nil
@@ -144,7 +162,7 @@ func (r repository) Delete(ctx context.Context, id int) error {
 	q := `
 		delete from users
 		where id = $1`
-	tag, err := r.client.Exec(ctx, q, id)
+	tag, err := r.conn(ctx).Exec(ctx, q, id)
 
 	if err != nil {
 		return err
@@ -159,3 +177,13 @@ func (r repository) Delete(ctx context.Context, id int) error {
 func NewRepository(client postgresql.Client) user.Repository {
 	return repository{client: client}
 }
+
+// now is the modification time stored by writes, at the precision
+// Postgres keeps.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+func (r repository) conn(ctx context.Context) postgresql.Client {
+	return postgresql.Conn(ctx, r.client)
+}
@@ -0,0 +1,36 @@
+package pgstore_test
+
+import (
+	"bulletin-board/internal/ad"
+	adPgstore "bulletin-board/internal/ad/repository/pgstore"
+	"bulletin-board/internal/user"
+	"bulletin-board/internal/user/pgstore"
+	"bulletin-board/internal/user/repotest"
+	"context"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"os"
+	"testing"
+)
+
+// TestRepository runs against the database in TEST_DATABASE_URL and
+// truncates the ads and users tables.
+func TestRepository(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+
+	repotest.Run(t, func(t *testing.T) (user.Repository, ad.Repository) {
+		if _, err := pool.Exec(ctx, "truncate ads, users restart identity cascade"); err != nil {
+			t.Fatal(err)
+		}
+		return pgstore.NewRepository(pool), adPgstore.NewRepository(pool)
+	})
+}
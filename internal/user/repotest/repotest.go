@@ -0,0 +1,190 @@
+// Package repotest is a conformance suite that every user.Repository
+// implementation must pass.
+package repotest
+
+import (
+	"bulletin-board/internal/ad"
+	"bulletin-board/internal/user"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// Factory returns an empty user repository and an ad repository backed
+// by the same storage.
+type Factory func(t *testing.T) (user.Repository, ad.Repository)
+
+var birthday = time.Date(1990, time.March, 4, 0, 0, 0, 0, time.UTC)
+
+func Run(t *testing.T, newRepo Factory) {
+	t.Run("CreateAndGet", func(t *testing.T) {
+		users, _ := newRepo(t)
+		ctx := context.Background()
+
+		created := mustCreate(t, users, "ann@example.com")
+		if created.ID <= 0 {
+			t.Fatalf("Create assigned id %d", created.ID)
+		}
+
+		got, err := users.GetByID(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		if got.Password != "" {
+			t.Fatal("GetByID must not return the password hash")
+		}
+		if got.Email != created.Email || got.Name != created.Name || !got.Birthday.Equal(birthday) {
+			t.Fatalf("GetByID = %+v, want %+v", got, created)
+		}
+		if got.Version != 1 || created.Version != 1 || got.UpdatedAt.IsZero() || !got.UpdatedAt.Equal(created.UpdatedAt) {
+			t.Fatalf("GetByID version %d at %v, want version 1 at %v", got.Version, got.UpdatedAt, created.UpdatedAt)
+		}
+		if created.Tier != user.TierBasic || got.Tier != user.TierBasic {
+			t.Fatalf("new user has tier %q, read back as %q, want %q", created.Tier, got.Tier, user.TierBasic)
+		}
+
+		byEmail, err := users.GetByEmail(ctx, created.Email)
+		if err != nil {
+			t.Fatalf("GetByEmail: %v", err)
+		}
+		if byEmail.ID != created.ID || byEmail.Password != "hash" {
+			t.Fatalf("GetByEmail = %+v, want id %d with password hash", byEmail, created.ID)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		users, _ := newRepo(t)
+		ctx := context.Background()
+
+		if _, err := users.GetByID(ctx, 424242); !errors.Is(err, user.ErrUserNotFound) {
+			t.Fatalf("GetByID error = %v, want user.ErrUserNotFound", err)
+		}
+		if _, err := users.GetByEmail(ctx, "nobody@example.com"); !errors.Is(err, user.ErrUserNotFound) {
+			t.Fatalf("GetByEmail error = %v, want user.ErrUserNotFound", err)
+		}
+		if _, err := users.Update(ctx, user.User{Name: "x", Birthday: birthday}, 424242); !errors.Is(err, user.ErrUserNotFound) {
+			t.Fatalf("Update error = %v, want user.ErrUserNotFound", err)
+		}
+		if err := users.Delete(ctx, 424242); !errors.Is(err, user.ErrUserNotFound) {
+			t.Fatalf("Delete error = %v, want user.ErrUserNotFound", err)
+		}
+	})
+
+	t.Run("DuplicateEmail", func(t *testing.T) {
+		users, _ := newRepo(t)
+		mustCreate(t, users, "dup@example.com")
+
+		_, err := users.Create(context.Background(), user.User{Name: "Other", Email: "dup@example.com", Password: "hash", Birthday: birthday})
+		if !errors.Is(err, user.ErrEmailTaken) {
+			t.Fatalf("Create error = %v, want user.ErrEmailTaken", err)
+		}
+	})
+
+	t.Run("GetAll", func(t *testing.T) {
+		users, _ := newRepo(t)
+		a := mustCreate(t, users, "a@example.com")
+		b := mustCreate(t, users, "b@example.com")
+
+		all, err := users.GetAll(context.Background())
+		if err != nil {
+			t.Fatalf("GetAll: %v", err)
+		}
+		if len(all) != 2 || all[0].ID != a.ID || all[1].ID != b.ID {
+			t.Fatalf("GetAll returned %+v, want users %d and %d in id order", all, a.ID, b.ID)
+		}
+		for _, u := range all {
+			if u.ID != a.ID && u.ID != b.ID {
+				t.Fatalf("GetAll returned unexpected user %+v", u)
+			}
+			if u.Password != "" {
+				t.Fatal("GetAll must not return password hashes")
+			}
+		}
+	})
+
+	t.Run("GetByIDs", func(t *testing.T) {
+		users, _ := newRepo(t)
+		a := mustCreate(t, users, "a@example.com")
+		b := mustCreate(t, users, "b@example.com")
+		mustCreate(t, users, "c@example.com")
+
+		got, err := users.GetByIDs(context.Background(), []int{a.ID, 424242})
+		if err != nil {
+			t.Fatalf("GetByIDs: %v", err)
+		}
+		if len(got) != 1 || got[0].ID != a.ID {
+			t.Fatalf("GetByIDs = %+v, want only user %d", got, a.ID)
+		}
+
+		got, err = users.GetByIDs(context.Background(), []int{b.ID, a.ID})
+		if err != nil {
+			t.Fatalf("GetByIDs: %v", err)
+		}
+		if len(got) != 2 || got[0].ID != a.ID || got[1].ID != b.ID {
+			t.Fatalf("GetByIDs = %+v, want users %d and %d in id order", got, a.ID, b.ID)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		users, _ := newRepo(t)
+		ctx := context.Background()
+		created := mustCreate(t, users, "upd@example.com")
+
+		newBirthday := birthday.AddDate(1, 0, 0)
+		updated, err := users.Update(ctx, user.User{Name: "New", Birthday: newBirthday, Contact: "@newcontact"}, created.ID)
+		if err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if updated.ID != created.ID || updated.Name != "New" || updated.Contact != "@newcontact" || !updated.Birthday.Equal(newBirthday) {
+			t.Fatalf("Update = %+v", updated)
+		}
+		if updated.Email != created.Email || updated.Version != 2 || updated.UpdatedAt.Before(created.UpdatedAt) {
+			t.Fatalf("Update = %+v, want email %s at version 2", updated, created.Email)
+		}
+
+		got, err := users.GetByID(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		if got.Name != "New" || got.Email != created.Email || !got.Birthday.Equal(newBirthday) || got.Version != 2 || !got.UpdatedAt.Equal(updated.UpdatedAt) || got.Tier != created.Tier {
+			t.Fatalf("GetByID after Update = %+v", got)
+		}
+	})
+
+	t.Run("DeleteRemovesAds", func(t *testing.T) {
+		users, ads := newRepo(t)
+		ctx := context.Background()
+		owner := mustCreate(t, users, "owner@example.com")
+
+		created, err := ads.Create(ctx, ad.Ad{Title: "mine", UserID: owner.ID})
+		if err != nil {
+			t.Fatalf("Create ad: %v", err)
+		}
+
+		if err := users.Delete(ctx, owner.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if _, err := users.GetByID(ctx, owner.ID); !errors.Is(err, user.ErrUserNotFound) {
+			t.Fatalf("GetByID after Delete error = %v, want user.ErrUserNotFound", err)
+		}
+		if _, err := ads.GetByID(ctx, created.ID); !errors.Is(err, ad.ErrNotFound) {
+			t.Fatalf("ad of deleted user error = %v, want ad.ErrNotFound", err)
+		}
+	})
+}
+
+func mustCreate(t *testing.T, repo user.Repository, email string) user.User {
+	t.Helper()
+	created, err := repo.Create(context.Background(), user.User{
+		Name:     "Ann",
+		Email:    email,
+		Password: "hash",
+		Birthday: birthday,
+		Contact:  "+7 999 123-45-67",
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return created
+}
@@ -1,9 +1,13 @@
 package service
 
 import (
+	"bulletin-board/internal/ad"
 	responseDto "bulletin-board/internal/ad/dto"
+	"bulletin-board/internal/uow"
 	"bulletin-board/internal/user"
 	"bulletin-board/internal/user/dto"
+	"bulletin-board/internal/validation"
+	"bulletin-board/pkg/tracing"
 	"context"
 	"errors"
 	"github.com/dgrijalva/jwt-go"
@@ -11,10 +15,13 @@ import (
 	"time"
 )
 
-const signingKey = "iuNvi8sa5oiHOajKfn93hFL93gb"
+// SigningKey is the key tokens are signed with.
+const SigningKey = "iuNvi8sa5oiHOajKfn93hFL93gb"
 
 type Service struct {
 	repository user.Repository
+	ads        ad.Repository
+	tx         uow.UnitOfWork
 }
 
 type TokenClaims struct {
@@ -22,8 +29,8 @@ type TokenClaims struct {
 	UserId int `json:"user_id"`
 }
 
-func NewService(repository user.Repository) *Service {
-	return &Service{repository: repository}
+func NewService(repository user.Repository, ads ad.Repository, tx uow.UnitOfWork) *Service {
+	return &Service{repository: repository, ads: ads, tx: tx}
 }
 
 func (s *Service) GetAll(ctx context.Context) ([]dto.ResponseUser, error) {
@@ -50,11 +57,39 @@ func (s *Service) GetByID(ctx context.Context, id int) (dto.ResponseUser, error)
 	return dto.ToDto(user), nil
 }
 
+// GetByIDs loads several users in one query. Unknown ids are absent
+// from the result.
+func (s *Service) GetByIDs(ctx context.Context, ids []int) (map[int]dto.ResponseUser, error) {
+	users, err := s.repository.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[int]dto.ResponseUser, len(users))
+	for _, usr := range users {
+		res[usr.ID] = dto.ToDto(usr)
+	}
+	return res, nil
+}
+
+// GetAdsByUserIDs loads the ads of several users in one query. Ads come
+// from the ad repository, which may live in another store than users.
+func (s *Service) GetAdsByUserIDs(ctx context.Context, userIds []int) (map[int][]responseDto.ResponseAd, error) {
+	ads, err := s.ads.GetByUserIDs(ctx, userIds)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[int][]responseDto.ResponseAd, len(userIds))
+	for _, ad := range ads {
+		res[ad.UserID] = append(res[ad.UserID], responseDto.ToDto(ad))
+	}
+	return res, nil
+}
+
 func (s *Service) GetUsersAds(ctx context.Context, userId int) ([]responseDto.ResponseAd, error) {
 	if userId < 1 {
 		return []responseDto.ResponseAd{}, user.ErrInvalidUserId
 	}
-	ads, err := s.repository.GetUsersAds(ctx, userId)
+	ads, err := s.ads.GetByUserIDs(ctx, []int{userId})
 	if err != nil {
 		return nil, err
 	}
@@ -68,8 +103,14 @@ func (s *Service) GetUsersAds(ctx context.Context, userId int) ([]responseDto.Re
 }
 
 func (s *Service) Create(ctx context.Context, newUser dto.RequestUser) (dto.ResponseUser, error) {
-	user := dto.ToUser(newUser)
-	hash, err := s.generatePasswordHash(user.Password)
+	if err := validation.Struct(newUser); err != nil {
+		return dto.ResponseUser{}, err
+	}
+	user, err := dto.ToUser(newUser)
+	if err != nil {
+		return dto.ResponseUser{}, err
+	}
+	hash, err := s.generatePasswordHash(ctx, user.Password)
 	if err != nil {
 		return dto.ResponseUser{}, err
 	}
@@ -85,7 +126,13 @@ func (s *Service) Update(ctx context.Context, requestUser dto.RequestUser, id in
 	if id < 1 {
 		return dto.ResponseUser{}, user.ErrInvalidUserId
 	}
-	user := dto.ToUser(requestUser)
+	if err := validation.Partial(requestUser, "name", "birthday", "contact"); err != nil {
+		return dto.ResponseUser{}, err
+	}
+	user, err := dto.ToUser(requestUser)
+	if err != nil {
+		return dto.ResponseUser{}, err
+	}
 	updatedUser, err := s.repository.Update(ctx, user, id)
 	if err != nil {
 		return dto.ResponseUser{}, err
@@ -93,15 +140,67 @@ func (s *Service) Update(ctx context.Context, requestUser dto.RequestUser, id in
 	return dto.ToDto(updatedUser), nil
 }
 
+// Patch applies fn to the user's current name, birthday and contact and
+// saves the result.
+func (s *Service) Patch(ctx context.Context, id int, fn func(*dto.PatchUser) error) (dto.ResponseUser, error) {
+	if id < 1 {
+		return dto.ResponseUser{}, user.ErrInvalidUserId
+	}
+
+	var patched user.User
+	err := s.tx.Do(ctx, func(ctx context.Context) error {
+		current, err := s.repository.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		doc := dto.ToPatch(current)
+		if err := fn(&doc); err != nil {
+			return err
+		}
+		requestUser := dto.RequestUser{Name: doc.Name, Birthday: doc.Birthday, Contact: doc.Contact}
+		if err := validation.Partial(requestUser, "name", "birthday", "contact"); err != nil {
+			return err
+		}
+
+		next, err := dto.ToUser(requestUser)
+		if err != nil {
+			return err
+		}
+		patched, err = s.repository.Update(ctx, next, id)
+		return err
+	})
+	if err != nil {
+		return dto.ResponseUser{}, err
+	}
+
+	return dto.ToDto(patched), nil
+}
+
 func (s *Service) Delete(ctx context.Context, id int) error {
 	if id < 1 {
 		return user.ErrInvalidUserId
 	}
-	return s.repository.Delete(ctx, id)
+	// Ads are deleted explicitly rather than relying on the foreign key
+	// cascade, since the ad repository may live in another store.
+	return s.tx.Do(ctx, func(ctx context.Context) error {
+		ads, err := s.ads.GetByUserIDs(ctx, []int{id})
+		if err != nil {
+			return err
+		}
+		for _, a := range ads {
+			if err := s.ads.Delete(ctx, a.ID); err != nil && !errors.Is(err, ad.ErrNotFound) {
+				return err
+			}
+		}
+		return s.repository.Delete(ctx, id)
+	})
 }
 
-func (s *Service) generatePasswordHash(password string) (string, error) {
+func (s *Service) generatePasswordHash(ctx context.Context, password string) (string, error) {
+	_, span := tracing.Start(ctx, "bcrypt.GenerateFromPassword")
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	tracing.End(span, err)
 	if err != nil {
 		return "", err
 	}
@@ -109,21 +208,27 @@ func (s *Service) generatePasswordHash(password string) (string, error) {
 }
 
 func (s *Service) GenerateToken(ctx context.Context, email string, password string) (string, error) {
-	user, err := s.repository.GetByEmail(ctx, email)
+	usr, err := s.repository.GetByEmail(ctx, email)
 	if err != nil {
-		return "", errors.New("user not found")
+		if errors.Is(err, user.ErrUserNotFound) {
+			return "", user.ErrInvalidCredentials
+		}
+		return "", err
 	}
 
-	if err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return "", errors.New("invalid password")
+	_, span := tracing.Start(ctx, "bcrypt.CompareHashAndPassword")
+	err = bcrypt.CompareHashAndPassword([]byte(usr.Password), []byte(password))
+	span.End()
+	if err != nil {
+		return "", user.ErrInvalidCredentials
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &TokenClaims{
 		jwt.StandardClaims{
 			ExpiresAt: time.Now().Add(12 * time.Hour).Unix(),
 			IssuedAt:  time.Now().Unix(),
-		}, user.ID,
+		}, usr.ID,
 	})
 
-	return token.SignedString([]byte(signingKey))
+	return token.SignedString([]byte(SigningKey))
 }
@@ -0,0 +1,40 @@
+package service_test
+
+import (
+	"bulletin-board/internal/ad"
+	"bulletin-board/internal/ad/repository/filestore"
+	"bulletin-board/internal/memstore"
+	"bulletin-board/internal/uow"
+	"bulletin-board/internal/user"
+	"bulletin-board/internal/user/service"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDeleteRemovesAdsInAnotherStore(t *testing.T) {
+	ctx := context.Background()
+	users := memstore.New().UserRepository()
+	ads, err := filestore.NewRepository(filepath.Join(t.TempDir(), "ads.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ads.Close() })
+	svc := service.NewService(users, ads, uow.NoOp())
+
+	owner, err := users.Create(ctx, user.User{Name: "Owner", Email: "owner@example.com", Birthday: time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ads.Create(ctx, ad.Ad{Title: "Bike", Price: 100, UserID: owner.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := svc.Delete(ctx, owner.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if left, _ := ads.GetAll(ctx); len(left) != 0 {
+		t.Fatalf("ads after deleting their owner = %+v, want none", left)
+	}
+}
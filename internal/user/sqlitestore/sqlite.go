@@ -0,0 +1,199 @@
+package sqlitestore
+
+import (
+	"bulletin-board/internal/apperror"
+	"bulletin-board/internal/user"
+	"bulletin-board/pkg/sqlite"
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+type repository struct {
+	db *sql.DB
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func (r repository) GetAll(ctx context.Context) ([]user.User, error) {
+	q := `
+		select id, name, email, birthday, contact, tier, version, updated_at
+		from users
+		order by id`
+	return r.queryUsers(ctx, q)
+}
+
+func (r repository) GetByID(ctx context.Context, id int) (user.User, error) {
+	q := `
+		select id, name, email, birthday, contact, tier, version, updated_at
+		from users
+		where id = ?`
+	usr, err := scanUser(r.conn(ctx).QueryRowContext(ctx, q, id), false)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return user.User{}, user.ErrUserNotFound
+		}
+		return user.User{}, err
+	}
+	return usr, nil
+}
+
+func (r repository) GetByIDs(ctx context.Context, ids []int) ([]user.User, error) {
+	if len(ids) == 0 {
+		return []user.User{}, nil
+	}
+	q := `
+		select id, name, email, birthday, contact, tier, version, updated_at
+		from users
+		where id in (` + placeholders(len(ids)) + `)
+		order by id`
+	return r.queryUsers(ctx, q, toArgs(ids)...)
+}
+
+func (r repository) GetByEmail(ctx context.Context, email string) (user.User, error) {
+	q := `
+		select id, name, email, password, birthday, contact, tier, version, updated_at
+		from users
+		where email = ?`
+	usr, err := scanUser(r.conn(ctx).QueryRowContext(ctx, q, email), true)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return user.User{}, user.ErrUserNotFound
+		}
+		return user.User{}, err
+	}
+	return usr, nil
+}
+
+func (r repository) Create(ctx context.Context, newUser user.User) (user.User, error) {
+	q := `
+		insert into users (name, email, password, birthday, contact, version, updated_at)
+		values (?, ?, ?, ?, ?, 1, ?)
+		returning id, tier, version, updated_at`
+
+	err := r.conn(ctx).QueryRowContext(ctx, q, newUser.Name, newUser.Email, newUser.Password, newUser.Birthday.Format(dateLayout), newUser.Contact, sqlite.FormatTime(time.Now())).
+		Scan(&newUser.ID, &newUser.Tier, &newUser.Version, sqlite.ScanTime(&newUser.UpdatedAt))
+	if err != nil {
+		if sqlite.IsUniqueViolation(err) {
+			return user.User{}, apperror.Wrap(user.ErrEmailTaken, err)
+		}
+		return user.User{}, err
+	}
+	return newUser, nil
+}
+
+func (r repository) Update(ctx context.Context, newUser user.User, id int) (user.User, error) {
+	q := `
+		update users
+		set
+			name = ?,
+			birthday = ?,
+			contact = ?,
+			version = version + 1,
+			updated_at = ?
+		where id = ?
+		returning id, email, tier, version, updated_at`
+
+	err := r.conn(ctx).QueryRowContext(ctx, q, newUser.Name, newUser.Birthday.Format(dateLayout), newUser.Contact, sqlite.FormatTime(time.Now()), id).
+		Scan(&newUser.ID, &newUser.Email, &newUser.Tier, &newUser.Version, sqlite.ScanTime(&newUser.UpdatedAt))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return user.User{}, user.ErrUserNotFound
+		}
+		return user.User{}, err
+	}
+
+	return newUser, nil
+}
+
+func (r repository) Delete(ctx context.Context, id int) error {
+	q := `
+		delete from users
+		where id = ?`
+	res, err := r.conn(ctx).ExecContext(ctx, q, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return user.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r repository) queryUsers(ctx context.Context, q string, args ...any) ([]user.User, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]user.User, 0)
+
+	for rows.Next() {
+		usr, err := scanUser(rows, false)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, usr)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// scanUser reads a user row; birthdays are stored as YYYY-MM-DD text.
+func scanUser(row scanner, withPassword bool) (user.User, error) {
+	var (
+		usr      user.User
+		birthday string
+		err      error
+	)
+	if withPassword {
+		err = row.Scan(&usr.ID, &usr.Name, &usr.Email, &usr.Password, &birthday, &usr.Contact, &usr.Tier, &usr.Version, sqlite.ScanTime(&usr.UpdatedAt))
+	} else {
+		err = row.Scan(&usr.ID, &usr.Name, &usr.Email, &birthday, &usr.Contact, &usr.Tier, &usr.Version, sqlite.ScanTime(&usr.UpdatedAt))
+	}
+	if err != nil {
+		return user.User{}, err
+	}
+
+	usr.Birthday, err = time.Parse(dateLayout, birthday)
+	if err != nil {
+		return user.User{}, err
+	}
+	return usr, nil
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func toArgs(ids []int) []any {
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return args
+}
+
+func NewRepository(db *sql.DB) user.Repository {
+	return repository{db: db}
+}
+
+func (r repository) conn(ctx context.Context) sqlite.DBTX {
+	return sqlite.Conn(ctx, r.db)
+}
@@ -0,0 +1,30 @@
+package sqlitestore_test
+
+import (
+	"bulletin-board/internal/ad"
+	adSqlitestore "bulletin-board/internal/ad/repository/sqlitestore"
+	"bulletin-board/internal/migrations"
+	"bulletin-board/internal/user"
+	"bulletin-board/internal/user/repotest"
+	"bulletin-board/internal/user/sqlitestore"
+	"bulletin-board/pkg/sqlite"
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestRepository(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) (user.Repository, ad.Repository) {
+		ctx := context.Background()
+		db, err := sqlite.NewClient(ctx, filepath.Join(t.TempDir(), "test.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = db.Close() })
+
+		if err := migrations.Up(ctx, migrations.NewSQLiteTarget(db), migrations.SQLite); err != nil {
+			t.Fatal(err)
+		}
+		return sqlitestore.NewRepository(db), adSqlitestore.NewRepository(db)
+	})
+}
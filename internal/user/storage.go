@@ -1,19 +1,20 @@
 package user
 
 import (
-	"bulletin-board/internal/ad"
+	"bulletin-board/internal/apperror"
 	"context"
-	"errors"
 )
 
 type Repository interface {
 	GetAll(ctx context.Context) ([]User, error)
 	GetByID(ctx context.Context, id int) (User, error)
+	GetByIDs(ctx context.Context, ids []int) ([]User, error)
 	GetByEmail(ctx context.Context, email string) (User, error)
-	GetUsersAds(ctx context.Context, userId int) ([]ad.Ad, error)
 	Create(ctx context.Context, newUser User) (User, error)
 	Update(ctx context.Context, user User, id int) (User, error)
 	Delete(ctx context.Context, id int) error
 }
 
-var ErrUserNotFound = errors.New("user not found")
+var ErrUserNotFound = apperror.NotFound("user not found")
+
+var ErrEmailTaken = apperror.Conflict("email already registered")
@@ -0,0 +1,67 @@
+package traced
+
+import (
+	"bulletin-board/internal/user"
+	"bulletin-board/pkg/tracing"
+	"context"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type repository struct {
+	next user.Repository
+}
+
+func (r repository) GetAll(ctx context.Context) (users []user.User, err error) {
+	ctx, span := tracing.Start(ctx, "user.Repository.GetAll")
+	defer func() { tracing.End(span, err) }()
+
+	users, err = r.next.GetAll(ctx)
+	span.SetAttributes(attribute.Int("users.count", len(users)))
+	return users, err
+}
+
+func (r repository) GetByID(ctx context.Context, id int) (_ user.User, err error) {
+	ctx, span := tracing.Start(ctx, "user.Repository.GetByID", attribute.Int("user.id", id))
+	defer func() { tracing.End(span, err) }()
+
+	return r.next.GetByID(ctx, id)
+}
+
+func (r repository) GetByIDs(ctx context.Context, ids []int) (users []user.User, err error) {
+	ctx, span := tracing.Start(ctx, "user.Repository.GetByIDs", attribute.IntSlice("user.ids", ids))
+	defer func() { tracing.End(span, err) }()
+
+	return r.next.GetByIDs(ctx, ids)
+}
+
+func (r repository) GetByEmail(ctx context.Context, email string) (_ user.User, err error) {
+	ctx, span := tracing.Start(ctx, "user.Repository.GetByEmail")
+	defer func() { tracing.End(span, err) }()
+
+	return r.next.GetByEmail(ctx, email)
+}
+
+func (r repository) Create(ctx context.Context, newUser user.User) (_ user.User, err error) {
+	ctx, span := tracing.Start(ctx, "user.Repository.Create")
+	defer func() { tracing.End(span, err) }()
+
+	return r.next.Create(ctx, newUser)
+}
+
+func (r repository) Update(ctx context.Context, usr user.User, id int) (_ user.User, err error) {
+	ctx, span := tracing.Start(ctx, "user.Repository.Update", attribute.Int("user.id", id))
+	defer func() { tracing.End(span, err) }()
+
+	return r.next.Update(ctx, usr, id)
+}
+
+func (r repository) Delete(ctx context.Context, id int) (err error) {
+	ctx, span := tracing.Start(ctx, "user.Repository.Delete", attribute.Int("user.id", id))
+	defer func() { tracing.End(span, err) }()
+
+	return r.next.Delete(ctx, id)
+}
+
+func NewRepository(next user.Repository) user.Repository {
+	return repository{next: next}
+}
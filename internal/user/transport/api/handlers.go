@@ -1,13 +1,17 @@
 package api
 
 import (
+	adDto "bulletin-board/internal/ad/dto"
+	"bulletin-board/internal/apperror"
+	"bulletin-board/internal/httpbody"
+	"bulletin-board/internal/patch"
 	"bulletin-board/internal/user"
 	"bulletin-board/internal/user/dto"
 	"bulletin-board/internal/user/service"
+	"bulletin-board/pkg/httpcache"
 	"encoding/json"
-	"errors"
+	"fmt"
 	"github.com/gorilla/mux"
-	"log"
 	"net/http"
 	"strconv"
 )
@@ -27,12 +31,19 @@ func NewHandler(service service.Service) *Handler {
 
 func (h *Handler) GetAll() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
 		users, err := h.service.GetAll(r.Context())
 		if err != nil {
-			writeJSONError(w, http.StatusInternalServerError, err.Error())
+			apperror.Write(w, r, err)
+			return
+		}
+		parts := make([]string, len(users))
+		for i, u := range users {
+			parts[i] = etag(u)
+		}
+		if httpcache.NotModified(w, r, httpcache.Validators{ETag: httpcache.WeakETag(parts...)}, cacheControl) {
 			return
 		}
+		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		_ = json.NewEncoder(w).Encode(users)
 	}
@@ -40,23 +51,21 @@ func (h *Handler) GetAll() http.HandlerFunc {
 
 func (h *Handler) GetByID() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		params := mux.Vars(r)
-		id, err := strconv.Atoi(params["id"])
+		id, err := parseID(r)
 		if err != nil {
-			writeJSONError(w, http.StatusBadRequest, err.Error())
+			apperror.Write(w, r, err)
 			return
 		}
 
 		oneUser, err := h.service.GetByID(r.Context(), id)
 		if err != nil {
-			if errors.Is(err, user.ErrUserNotFound) {
-				writeJSONError(w, http.StatusNotFound, err.Error())
-			} else {
-				writeJSONError(w, http.StatusInternalServerError, err.Error())
-			}
+			apperror.Write(w, r, err)
+			return
+		}
+		if httpcache.NotModified(w, r, validators(oneUser), cacheControl) {
 			return
 		}
+		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		_ = json.NewEncoder(w).Encode(oneUser)
 	}
@@ -64,22 +73,24 @@ func (h *Handler) GetByID() http.HandlerFunc {
 
 func (h *Handler) GetUsersAds() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		params := mux.Vars(r)
-		id, err := strconv.Atoi(params["id"])
+		id, err := parseID(r)
 		if err != nil {
-			writeJSONError(w, http.StatusBadRequest, err.Error())
+			apperror.Write(w, r, err)
 			return
 		}
 		ads, err := h.service.GetUsersAds(r.Context(), id)
 		if err != nil {
-			if errors.Is(err, user.ErrUserNotFound) {
-				writeJSONError(w, http.StatusNotFound, err.Error())
-			} else {
-				writeJSONError(w, http.StatusInternalServerError, err.Error())
-			}
+			apperror.Write(w, r, err)
+			return
+		}
+		parts := make([]string, len(ads))
+		for i, a := range ads {
+			parts[i] = adETag(a)
+		}
+		if httpcache.NotModified(w, r, httpcache.Validators{ETag: httpcache.WeakETag(parts...)}, cacheControl) {
 			return
 		}
+		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		_ = json.NewEncoder(w).Encode(ads)
 	}
@@ -87,20 +98,21 @@ func (h *Handler) GetUsersAds() http.HandlerFunc {
 
 func (h *Handler) Create() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
 		var requestUser dto.RequestUser
-		err := json.NewDecoder(r.Body).Decode(&requestUser)
+		err := httpbody.DecodeJSON(r, &requestUser)
 		if err != nil {
-			writeJSONError(w, http.StatusBadRequest, err.Error())
+			apperror.Write(w, r, err)
 			return
 		}
 
 		responseUser, err := h.service.Create(r.Context(), requestUser)
 		if err != nil {
-			writeJSONError(w, http.StatusInternalServerError, err.Error())
+			apperror.Write(w, r, err)
 			return
 		}
 
+		httpcache.SetValidators(w, validators(responseUser))
+		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusCreated)
 		_ = json.NewEncoder(w).Encode(responseUser)
 	}
@@ -108,20 +120,22 @@ func (h *Handler) Create() http.HandlerFunc {
 
 func (h *Handler) SignIn() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
 		var requestUser signInInput
-		err := json.NewDecoder(r.Body).Decode(&requestUser)
+		err := httpbody.DecodeJSON(r, &requestUser)
 		if err != nil {
-			writeJSONError(w, http.StatusBadRequest, err.Error())
+			apperror.Write(w, r, err)
 			return
 		}
 
 		token, err := h.service.GenerateToken(r.Context(), requestUser.Email, requestUser.Password)
 		if err != nil {
-			writeJSONError(w, http.StatusInternalServerError, err.Error())
+			apperror.Write(w, r, err)
 			return
 		}
 
+		// Tokens must not be kept by caches or replayed by Idempotency.
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusCreated)
 		_ = json.NewEncoder(w).Encode(token)
 	}
@@ -129,34 +143,74 @@ func (h *Handler) SignIn() http.HandlerFunc {
 
 func (h *Handler) Update() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
 		var requestUser dto.RequestUser
-		params := mux.Vars(r)
-		id, err := strconv.Atoi(params["id"])
+		id, err := parseID(r)
+		if err != nil {
+			apperror.Write(w, r, err)
+			return
+		}
 
 		userId, ok := r.Context().Value("user_id").(int)
 		if !ok || userId != id {
-			writeJSONError(w, http.StatusForbidden, "forbidden")
+			apperror.Write(w, r, errForbidden)
 			return
 		}
 
+		err = httpbody.DecodeJSON(r, &requestUser)
+		if err != nil {
+			apperror.Write(w, r, err)
+			return
+		}
+		user, err := h.service.Update(r.Context(), requestUser, id)
 		if err != nil {
-			writeJSONError(w, http.StatusBadRequest, err.Error())
+			apperror.Write(w, r, err)
 			return
 		}
+		httpcache.SetValidators(w, validators(user))
 
-		err = json.NewDecoder(r.Body).Decode(&requestUser)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(user)
+	}
+}
 
+// Patch applies a JSON Merge Patch or JSON Patch to the authenticated
+// user's name, birthday and contact.
+func (h *Handler) Patch() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r)
 		if err != nil {
-			writeJSONError(w, http.StatusBadRequest, err.Error())
+			apperror.Write(w, r, err)
 			return
 		}
-		user, err := h.service.Update(r.Context(), requestUser, id)
+
+		userId, ok := r.Context().Value("user_id").(int)
+		if !ok || userId != id {
+			apperror.Write(w, r, errForbidden)
+			return
+		}
+
+		mediaType, err := patch.MediaType(r)
+		if err != nil {
+			apperror.Write(w, r, err)
+			return
+		}
+		body, err := httpbody.Read(r)
+		if err != nil {
+			apperror.Write(w, r, err)
+			return
+		}
+
+		user, err := h.service.Patch(r.Context(), id, func(doc *dto.PatchUser) error {
+			return patch.Apply(mediaType, body, doc)
+		})
 		if err != nil {
-			writeJSONError(w, http.StatusInternalServerError, err.Error())
+			apperror.Write(w, r, err)
 			return
 		}
 
+		httpcache.SetValidators(w, validators(user))
+		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		_ = json.NewEncoder(w).Encode(user)
 	}
@@ -164,31 +218,49 @@ func (h *Handler) Update() http.HandlerFunc {
 
 func (h *Handler) Delete() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		params := mux.Vars(r)
-		id, err := strconv.Atoi(params["id"])
+		id, err := parseID(r)
+		if err != nil {
+			apperror.Write(w, r, err)
+			return
+		}
 
 		userId, ok := r.Context().Value("user_id").(int)
 		if !ok || userId != id {
-			writeJSONError(w, http.StatusForbidden, "forbidden")
+			apperror.Write(w, r, errForbidden)
 			return
 		}
 
-		if err != nil {
-			writeJSONError(w, http.StatusBadRequest, err.Error())
-			return
-		}
 		err = h.service.Delete(r.Context(), id)
 		if err != nil {
-			writeJSONError(w, http.StatusInternalServerError, err.Error())
+			apperror.Write(w, r, err)
 			return
 		}
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
 
-func writeJSONError(w http.ResponseWriter, status int, message string) {
-	w.WriteHeader(status)
-	_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
-	log.Printf("Status: %d | Message: %s", status, message)
+// cacheControl keeps user data out of shared caches, which would
+// otherwise hand one user's email to another client.
+const cacheControl = "private, no-cache"
+
+var errForbidden = apperror.Forbidden("forbidden")
+
+func parseID(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		return 0, user.ErrInvalidUserId
+	}
+	return id, nil
+}
+
+func etag(u dto.ResponseUser) string {
+	return httpcache.ETag(fmt.Sprintf("user-%d-v%d", u.ID, u.Version))
+}
+
+func adETag(a adDto.ResponseAd) string {
+	return httpcache.ETag(fmt.Sprintf("ad-%d-v%d", a.ID, a.Version))
+}
+
+func validators(u dto.ResponseUser) httpcache.Validators {
+	return httpcache.Validators{ETag: etag(u), LastModified: u.UpdatedAt}
 }
@@ -0,0 +1,65 @@
+package api
+
+import (
+	adDto "bulletin-board/internal/ad/dto"
+	adServ "bulletin-board/internal/ad/service"
+	"bulletin-board/internal/memstore"
+	"bulletin-board/internal/uow"
+	"bulletin-board/internal/user/dto"
+	"bulletin-board/internal/user/service"
+	"context"
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConditionalGets(t *testing.T) {
+	t.Setenv("SINGING_KEY", service.SigningKey)
+	ctx := context.Background()
+	db := memstore.New()
+	users := service.NewService(db.UserRepository(), db.AdRepository(), uow.NoOp())
+	ads := adServ.NewService(db.AdRepository(), db.UserRepository(), uow.NoOp(), nil)
+
+	annie, err := users.Create(ctx, dto.RequestUser{Name: "Annie", Email: "annie@example.com", Password: "Secret123", Birthday: "1990-01-01"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ads.Create(ctx, adDto.RequestAd{Title: "Bike", Price: 100, UserID: annie.ID}); err != nil {
+		t.Fatal(err)
+	}
+	r := mux.NewRouter()
+	NewHandler(*users).NewRouter(r)
+
+	serve := func(path string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header = header
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, path := range []string{"/users", fmt.Sprintf("/users/%d", annie.ID), fmt.Sprintf("/users/%d/ads", annie.ID)} {
+		w := serve(path, http.Header{})
+		etag := w.Header().Get("ETag")
+		if w.Code != http.StatusOK || etag == "" {
+			t.Fatalf("GET %s = %d with ETag %q", path, w.Code, etag)
+		}
+		if w := serve(path, http.Header{"If-None-Match": {etag}}); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+			t.Errorf("GET %s with If-None-Match = %d %q, want an empty 304", path, w.Code, w.Body)
+		}
+		if w := serve(path, http.Header{"If-None-Match": {`W/"stale"`}}); w.Code != http.StatusOK {
+			t.Errorf("GET %s with a stale If-None-Match = %d, want 200", path, w.Code)
+		}
+	}
+
+	path := fmt.Sprintf("/users/%d", annie.ID)
+	lastModified := serve(path, http.Header{}).Header().Get("Last-Modified")
+	if lastModified == "" {
+		t.Fatalf("GET %s has no Last-Modified", path)
+	}
+	if w := serve(path, http.Header{"If-Modified-Since": {lastModified}}); w.Code != http.StatusNotModified {
+		t.Errorf("GET %s with If-Modified-Since = %d, want 304", path, w.Code)
+	}
+}
@@ -18,5 +18,6 @@ func (h Handler) NewRouter(r *mux.Router) {
 	secured.Use(middleware.AuthMiddleware(secretKey))
 
 	secured.HandleFunc("/{id}", h.Update()).Methods("PUT")
+	secured.HandleFunc("/{id}", h.Patch()).Methods("PATCH")
 	secured.HandleFunc("/{id}", h.Delete()).Methods("DELETE")
 }
@@ -0,0 +1,121 @@
+package grpcapi
+
+import (
+	adGrpc "bulletin-board/internal/ad/transport/grpcapi"
+	"bulletin-board/internal/apperror"
+	"bulletin-board/internal/user/dto"
+	"bulletin-board/internal/user/service"
+	bulletinv1 "bulletin-board/pkg/pb/bulletin/v1"
+	"context"
+	"google.golang.org/grpc"
+)
+
+// SecuredMethods lists the RPCs that need an authenticated user.
+var SecuredMethods = []string{
+	bulletinv1.UserService_UpdateUser_FullMethodName,
+	bulletinv1.UserService_DeleteUser_FullMethodName,
+}
+
+var errForbidden = apperror.Forbidden("forbidden")
+
+type Server struct {
+	bulletinv1.UnimplementedUserServiceServer
+	service *service.Service
+}
+
+func NewServer(service *service.Service) *Server {
+	return &Server{service: service}
+}
+
+func (s *Server) Register(gs *grpc.Server) {
+	bulletinv1.RegisterUserServiceServer(gs, s)
+}
+
+func (s *Server) ListUsers(ctx context.Context, _ *bulletinv1.ListUsersRequest) (*bulletinv1.ListUsersResponse, error) {
+	users, err := s.service.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*bulletinv1.User, 0, len(users))
+	for _, usr := range users {
+		res = append(res, toProto(usr))
+	}
+	return &bulletinv1.ListUsersResponse{Users: res}, nil
+}
+
+func (s *Server) GetUser(ctx context.Context, req *bulletinv1.GetUserRequest) (*bulletinv1.GetUserResponse, error) {
+	usr, err := s.service.GetByID(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, err
+	}
+	return &bulletinv1.GetUserResponse{User: toProto(usr)}, nil
+}
+
+func (s *Server) ListUserAds(ctx context.Context, req *bulletinv1.ListUserAdsRequest) (*bulletinv1.ListUserAdsResponse, error) {
+	ads, err := s.service.GetUsersAds(ctx, int(req.GetUserId()))
+	if err != nil {
+		return nil, err
+	}
+	return &bulletinv1.ListUserAdsResponse{Ads: adGrpc.ToProtoList(ads)}, nil
+}
+
+func (s *Server) CreateUser(ctx context.Context, req *bulletinv1.CreateUserRequest) (*bulletinv1.CreateUserResponse, error) {
+	usr, err := s.service.Create(ctx, dto.RequestUser{
+		Name:     req.GetName(),
+		Email:    req.GetEmail(),
+		Password: req.GetPassword(),
+		Birthday: req.GetBirthday(),
+		Contact:  req.GetContact(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &bulletinv1.CreateUserResponse{User: toProto(usr)}, nil
+}
+
+func (s *Server) SignIn(ctx context.Context, req *bulletinv1.SignInRequest) (*bulletinv1.SignInResponse, error) {
+	token, err := s.service.GenerateToken(ctx, req.GetEmail(), req.GetPassword())
+	if err != nil {
+		return nil, err
+	}
+	return &bulletinv1.SignInResponse{Token: token}, nil
+}
+
+func (s *Server) UpdateUser(ctx context.Context, req *bulletinv1.UpdateUserRequest) (*bulletinv1.UpdateUserResponse, error) {
+	id := int(req.GetId())
+	if userId, ok := ctx.Value("user_id").(int); !ok || userId != id {
+		return nil, errForbidden
+	}
+
+	usr, err := s.service.Update(ctx, dto.RequestUser{
+		Name:     req.GetName(),
+		Birthday: req.GetBirthday(),
+		Contact:  req.GetContact(),
+	}, id)
+	if err != nil {
+		return nil, err
+	}
+	return &bulletinv1.UpdateUserResponse{User: toProto(usr)}, nil
+}
+
+func (s *Server) DeleteUser(ctx context.Context, req *bulletinv1.DeleteUserRequest) (*bulletinv1.DeleteUserResponse, error) {
+	id := int(req.GetId())
+	if userId, ok := ctx.Value("user_id").(int); !ok || userId != id {
+		return nil, errForbidden
+	}
+
+	if err := s.service.Delete(ctx, id); err != nil {
+		return nil, err
+	}
+	return &bulletinv1.DeleteUserResponse{}, nil
+}
+
+func toProto(usr dto.ResponseUser) *bulletinv1.User {
+	return &bulletinv1.User{
+		Id:       int64(usr.ID),
+		Name:     usr.Name,
+		Email:    usr.Email,
+		Birthday: usr.Birthday,
+		Contact:  usr.Contact,
+	}
+}
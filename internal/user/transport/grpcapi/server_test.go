@@ -0,0 +1,127 @@
+package grpcapi
+
+import (
+	"bulletin-board/internal/grpctest"
+	"bulletin-board/internal/memstore"
+	"bulletin-board/internal/uow"
+	"bulletin-board/internal/user/service"
+	bulletinv1 "bulletin-board/pkg/pb/bulletin/v1"
+	"context"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func newClient(t *testing.T) bulletinv1.UserServiceClient {
+	t.Helper()
+	db := memstore.New()
+	users := service.NewService(db.UserRepository(), db.AdRepository(), uow.NoOp())
+
+	return bulletinv1.NewUserServiceClient(grpctest.Dial(t, SecuredMethods, NewServer(users).Register))
+}
+
+// signUp registers a user and returns its id and a context carrying its
+// token.
+func signUp(t *testing.T, client bulletinv1.UserServiceClient, email string) (int64, context.Context) {
+	t.Helper()
+	ctx := context.Background()
+	created, err := client.CreateUser(ctx, &bulletinv1.CreateUserRequest{
+		Name:     "Annie",
+		Email:    email,
+		Password: "Secret123",
+		Birthday: "1990-01-01",
+		Contact:  "@annie",
+	})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	signedIn, err := client.SignIn(ctx, &bulletinv1.SignInRequest{Email: email, Password: "Secret123"})
+	if err != nil {
+		t.Fatalf("SignIn: %v", err)
+	}
+	return created.GetUser().GetId(), metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+signedIn.GetToken())
+}
+
+func TestSecuredMethods(t *testing.T) {
+	client := newClient(t)
+	id, _ := signUp(t, client, "annie@example.com")
+	ctx := context.Background()
+
+	_, err := client.UpdateUser(ctx, &bulletinv1.UpdateUserRequest{Id: id, Name: "Ann", Birthday: "1990-01-01"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("UpdateUser without a token = %v, want Unauthenticated", err)
+	}
+	_, err = client.DeleteUser(ctx, &bulletinv1.DeleteUserRequest{Id: id})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("DeleteUser without a token = %v, want Unauthenticated", err)
+	}
+
+	bad := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer nonsense")
+	if _, err := client.DeleteUser(bad, &bulletinv1.DeleteUserRequest{Id: id}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("DeleteUser with a bad token = %v, want Unauthenticated", err)
+	}
+	if _, err := client.GetUser(ctx, &bulletinv1.GetUserRequest{Id: id}); err != nil {
+		t.Errorf("GetUser without a token: %v", err)
+	}
+}
+
+func TestForbidden(t *testing.T) {
+	client := newClient(t)
+	annie, annieCtx := signUp(t, client, "annie@example.com")
+	bob, _ := signUp(t, client, "bob@example.com")
+
+	_, err := client.UpdateUser(annieCtx, &bulletinv1.UpdateUserRequest{Id: bob, Name: "Robert", Birthday: "1990-01-01"})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("UpdateUser of another user = %v, want PermissionDenied", err)
+	}
+	if _, err := client.DeleteUser(annieCtx, &bulletinv1.DeleteUserRequest{Id: bob}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("DeleteUser of another user = %v, want PermissionDenied", err)
+	}
+	if got, err := client.GetUser(context.Background(), &bulletinv1.GetUserRequest{Id: bob}); err != nil || got.GetUser().GetName() != "Annie" {
+		t.Fatalf("forbidden calls changed the user: %v, %v", got, err)
+	}
+
+	updated, err := client.UpdateUser(annieCtx, &bulletinv1.UpdateUserRequest{Id: annie, Name: "Ann", Birthday: "1990-01-01", Contact: "@annie"})
+	if err != nil || updated.GetUser().GetName() != "Ann" {
+		t.Fatalf("UpdateUser of oneself = %v, %v", updated, err)
+	}
+	if _, err := client.DeleteUser(annieCtx, &bulletinv1.DeleteUserRequest{Id: annie}); err != nil {
+		t.Fatalf("DeleteUser of oneself: %v", err)
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	client := newClient(t)
+	ctx := context.Background()
+	signUp(t, client, "annie@example.com")
+
+	_, err := client.CreateUser(ctx, &bulletinv1.CreateUserRequest{Name: "A", Email: "not an email", Password: "Secret123", Birthday: "1990-01-01"})
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("CreateUser with invalid fields = %v, want InvalidArgument", err)
+	}
+	violations := map[string]bool{}
+	for _, d := range st.Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			for _, v := range br.GetFieldViolations() {
+				violations[v.GetField()] = v.GetDescription() != ""
+			}
+		}
+	}
+	if !violations["name"] || !violations["email"] || len(violations) != 2 {
+		t.Errorf("field violations = %v, want name and email", violations)
+	}
+
+	_, err = client.CreateUser(ctx, &bulletinv1.CreateUserRequest{Name: "Annie", Email: "annie@example.com", Password: "Secret123", Birthday: "1990-01-01"})
+	if status.Code(err) != codes.AlreadyExists {
+		t.Errorf("CreateUser with a taken email = %v, want AlreadyExists", err)
+	}
+	if _, err := client.GetUser(ctx, &bulletinv1.GetUserRequest{Id: 424242}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetUser of a missing user = %v, want NotFound", err)
+	}
+	if _, err := client.SignIn(ctx, &bulletinv1.SignInRequest{Email: "annie@example.com", Password: "Wrong1234"}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("SignIn with a wrong password = %v, want Unauthenticated", err)
+	}
+}
@@ -1,7 +1,7 @@
 package user
 
 import (
-	"errors"
+	"bulletin-board/internal/apperror"
 	"time"
 )
 
@@ -12,6 +12,19 @@ type User struct {
 	Password string    `json:"password"`
 	Birthday time.Time `json:"birthday"`
 	Contact  string    `json:"contact"`
+	// Tier decides the user's ad quotas. Users sign up as TierBasic.
+	Tier string `json:"tier"`
+	// Version and UpdatedAt change on every update.
+	Version   int       `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
-var ErrInvalidUserId = errors.New("invalid id")
+const (
+	TierBasic    = "basic"
+	TierVerified = "verified"
+	TierPremium  = "premium"
+)
+
+var ErrInvalidUserId = apperror.Validation("invalid id", apperror.FieldError{Field: "id", Message: "must be a positive integer"})
+
+var ErrInvalidCredentials = apperror.Unauthorized("invalid email or password")
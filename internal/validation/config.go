@@ -0,0 +1,66 @@
+package validation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// LoadFile reads rule overrides from a JSON file. Overrides are checked
+// before they are applied, so a broken file keeps the current rules.
+func (v *Validator) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read validation rules: %w", err)
+	}
+
+	var o Overrides
+	if err := json.Unmarshal(data, &o); err != nil {
+		return fmt.Errorf("parse validation rules: %w", err)
+	}
+
+	for typeName, fields := range o {
+		for field, tag := range fields {
+			if _, err := parseRules(tag); err != nil {
+				return fmt.Errorf("validation rules %s.%s: %w", typeName, field, err)
+			}
+		}
+	}
+
+	v.SetOverrides(o)
+	return nil
+}
+
+// Watch reloads the rules file whenever its modification time changes,
+// so rules can be tuned without a redeploy.
+func (v *Validator) Watch(ctx context.Context, path string, interval time.Duration) {
+	var lastMod time.Time
+	if info, err := os.Stat(path); err == nil {
+		lastMod = info.ModTime()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(path)
+			if err != nil || !info.ModTime().After(lastMod) {
+				continue
+			}
+			lastMod = info.ModTime()
+
+			if err := v.LoadFile(path); err != nil {
+				slog.ErrorContext(ctx, "reload validation rules", slog.Any("error", err))
+				continue
+			}
+			slog.InfoContext(ctx, "validation rules reloaded", slog.String("path", path))
+		}
+	}
+}
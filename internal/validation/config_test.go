@@ -0,0 +1,45 @@
+package validation
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	v := New()
+	write(`{"input": {"title": "required,max=5"}}`)
+	if err := v.LoadFile(path); err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if err := v.Struct(input{Title: "Bicycle", Price: 1}); err == nil {
+		t.Fatal("loaded override was not applied")
+	}
+
+	for _, content := range []string{
+		`{"input": {"title": "max=abc"}}`,
+		`{"input": {"title": "max"}}`,
+		`{"input": {"title": "required,nope"}}`,
+		`{"input": {"title": "email=1"}}`,
+		`{"input": `,
+	} {
+		write(content)
+		if err := v.LoadFile(path); err == nil {
+			t.Errorf("LoadFile(%s) succeeded", content)
+		}
+		if err := v.Struct(input{Title: "Bicycle", Price: 1}); err == nil {
+			t.Errorf("LoadFile(%s) replaced the current rules", content)
+		}
+	}
+
+	if err := v.LoadFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("LoadFile of a missing file succeeded")
+	}
+}
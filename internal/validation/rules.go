@@ -0,0 +1,138 @@
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const DateLayout = "2006-01-02"
+
+// checkFunc checks a non-empty field; n is the rule's parameter.
+type checkFunc func(field reflect.Value, n int) string
+
+var checks = map[string]checkFunc{
+	"min":      checkMin,
+	"max":      checkMax,
+	"email":    checkEmail,
+	"password": checkPassword,
+	"date":     checkDate,
+	"minage":   checkMinAge,
+	"maxage":   checkMaxAge,
+	"contact":  checkContact,
+}
+
+// params lists the rules that take a non-negative integer parameter,
+// with the value used when it is left out; -1 makes it mandatory.
+var params = map[string]int{
+	"min":      -1,
+	"max":      -1,
+	"password": 8,
+	"minage":   -1,
+	"maxage":   -1,
+}
+
+var (
+	phoneRe    = regexp.MustCompile(`^\+?[0-9][0-9 ()-]{5,18}[0-9]$`)
+	usernameRe = regexp.MustCompile(`^@[A-Za-z0-9_]{5,32}$`)
+)
+
+func checkMin(field reflect.Value, n int) string {
+	switch field.Kind() {
+	case reflect.String:
+		if utf8.RuneCountInString(field.String()) < n {
+			return fmt.Sprintf("must be at least %d characters", n)
+		}
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		if field.Int() < int64(n) {
+			return fmt.Sprintf("must be at least %d", n)
+		}
+	}
+	return ""
+}
+
+func checkMax(field reflect.Value, n int) string {
+	switch field.Kind() {
+	case reflect.String:
+		if utf8.RuneCountInString(field.String()) > n {
+			return fmt.Sprintf("must be at most %d characters", n)
+		}
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		if field.Int() > int64(n) {
+			return fmt.Sprintf("must be at most %d", n)
+		}
+	}
+	return ""
+}
+
+func checkEmail(field reflect.Value, _ int) string {
+	addr, err := mail.ParseAddress(field.String())
+	if err != nil || addr.Address != field.String() {
+		return "must be a valid email address"
+	}
+	return ""
+}
+
+// checkPassword requires a minimum length (8 by default) and a mix of
+// upper case letters, lower case letters and digits.
+func checkPassword(field reflect.Value, minLen int) string {
+	s := field.String()
+	var upper, lower, digit bool
+	for _, r := range s {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+
+	if utf8.RuneCountInString(s) < minLen || !upper || !lower || !digit {
+		return fmt.Sprintf("must be at least %d characters and contain upper case, lower case and digits", minLen)
+	}
+	return ""
+}
+
+func checkDate(field reflect.Value, _ int) string {
+	if _, err := time.Parse(DateLayout, field.String()); err != nil {
+		return "must be a date in YYYY-MM-DD format"
+	}
+	return ""
+}
+
+func checkMinAge(field reflect.Value, n int) string {
+	date, err := time.Parse(DateLayout, field.String())
+	if err != nil {
+		return "must be a date in YYYY-MM-DD format"
+	}
+	if date.After(time.Now().AddDate(-n, 0, 0)) {
+		return fmt.Sprintf("age must be at least %d", n)
+	}
+	return ""
+}
+
+func checkMaxAge(field reflect.Value, n int) string {
+	date, err := time.Parse(DateLayout, field.String())
+	if err != nil {
+		return "must be a date in YYYY-MM-DD format"
+	}
+	if date.Before(time.Now().AddDate(-n, 0, 0)) {
+		return fmt.Sprintf("age must be at most %d", n)
+	}
+	return ""
+}
+
+// checkContact accepts a phone number, an email address or a @username.
+func checkContact(field reflect.Value, _ int) string {
+	s := field.String()
+	if phoneRe.MatchString(s) || usernameRe.MatchString(s) || checkEmail(field, 0) == "" {
+		return ""
+	}
+	return "must be a phone number, email address or @username"
+}
@@ -0,0 +1,170 @@
+package validation
+
+import (
+	"bulletin-board/internal/apperror"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+const tagName = "validate"
+
+// Overrides replaces the tag rules of individual fields, keyed by struct
+// type name and then by the field's JSON name, e.g.
+// {"RequestAd": {"title": "required,max=200"}}.
+type Overrides map[string]map[string]string
+
+type Validator struct {
+	overrides atomic.Pointer[Overrides]
+}
+
+func New() *Validator {
+	v := &Validator{}
+	v.SetOverrides(nil)
+	return v
+}
+
+var defaultValidator = New()
+
+func Default() *Validator {
+	return defaultValidator
+}
+
+// Struct validates every field of s and reports all failures at once.
+func Struct(s any) error {
+	return defaultValidator.Struct(s)
+}
+
+// Partial validates only the named fields of s.
+func Partial(s any, fields ...string) error {
+	return defaultValidator.Partial(s, fields...)
+}
+
+func (v *Validator) SetOverrides(o Overrides) {
+	if o == nil {
+		o = Overrides{}
+	}
+	v.overrides.Store(&o)
+}
+
+func (v *Validator) Struct(s any) error {
+	return v.validate(s, nil)
+}
+
+func (v *Validator) Partial(s any, fields ...string) error {
+	only := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		only[f] = true
+	}
+	return v.validate(s, only)
+}
+
+func (v *Validator) validate(s any, only map[string]bool) error {
+	rv := reflect.Indirect(reflect.ValueOf(s))
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("validation: expected struct, got %s", rv.Kind())
+	}
+	rt := rv.Type()
+	overrides := (*v.overrides.Load())[rt.Name()]
+
+	var fieldErrors []apperror.FieldError
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		name := fieldName(sf)
+		if only != nil && !only[name] {
+			continue
+		}
+
+		tag, ok := overrides[name]
+		if !ok {
+			tag = sf.Tag.Get(tagName)
+		}
+		if tag == "" || tag == "-" {
+			continue
+		}
+
+		rules, err := parseRules(tag)
+		if err != nil {
+			return fmt.Errorf("validation: %s.%s: %w", rt.Name(), name, err)
+		}
+
+		if msg := check(rv.Field(i), rules); msg != "" {
+			fieldErrors = append(fieldErrors, apperror.FieldError{Field: name, Message: msg})
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return apperror.Validation("validation failed", fieldErrors...)
+	}
+	return nil
+}
+
+type rule struct {
+	name  string
+	param int
+}
+
+// parseRules parses a tag such as "required,min=3,max=100", rejecting
+// unknown rules and missing or malformed parameters.
+func parseRules(tag string) ([]rule, error) {
+	parts := strings.Split(tag, ",")
+	rules := make([]rule, 0, len(parts))
+	for _, p := range parts {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		name, param, hasParam := strings.Cut(p, "=")
+		if _, ok := checks[name]; !ok && name != "required" {
+			return nil, fmt.Errorf("unknown rule %q", name)
+		}
+
+		n, takesParam := params[name]
+		switch {
+		case !takesParam && hasParam:
+			return nil, fmt.Errorf("rule %q takes no parameter", name)
+		case takesParam && hasParam:
+			var err error
+			if n, err = strconv.Atoi(param); err != nil || n < 0 {
+				return nil, fmt.Errorf("rule %q needs a non-negative integer, got %q", name, param)
+			}
+		case takesParam && n < 0:
+			return nil, fmt.Errorf("rule %q needs a parameter", name)
+		}
+		rules = append(rules, rule{name: name, param: n})
+	}
+	return rules, nil
+}
+
+// check returns the message of the first failed rule. Empty values are
+// only checked by "required", so optional fields may be omitted.
+func check(field reflect.Value, rules []rule) string {
+	if field.IsZero() {
+		for _, r := range rules {
+			if r.name == "required" {
+				return "is required"
+			}
+		}
+		return ""
+	}
+
+	for _, r := range rules {
+		if r.name == "required" {
+			continue
+		}
+		if msg := checks[r.name](field, r.param); msg != "" {
+			return msg
+		}
+	}
+	return ""
+}
+
+func fieldName(sf reflect.StructField) string {
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return sf.Name
+	}
+	return name
+}
@@ -0,0 +1,143 @@
+package validation
+
+import (
+	"bulletin-board/internal/apperror"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type input struct {
+	Title string `json:"title" validate:"required,min=3,max=10"`
+	Price int    `json:"price" validate:"min=1"`
+	Note  string `json:"note"`
+}
+
+func TestRules(t *testing.T) {
+	age := func(years int) string {
+		return time.Now().AddDate(-years, 0, -1).Format(DateLayout)
+	}
+
+	tests := []struct {
+		tag   string
+		value any
+		want  string
+	}{
+		{"required", "x", ""},
+		{"required", "", "is required"},
+		{"required", 0, "is required"},
+		{"min=3", "abc", ""},
+		{"min=3", "ab", "must be at least 3 characters"},
+		{"min=3", "ÿÿÿ", ""},
+		{"min=3", "", ""},
+		{"min=0", -1, "must be at least 0"},
+		{"min=1", 1, ""},
+		{"max=3", "abc", ""},
+		{"max=3", "abcd", "must be at most 3 characters"},
+		{"max=10", 11, "must be at most 10"},
+		{"max=10", 10, ""},
+		{"email", "annie@example.com", ""},
+		{"email", "Annie <annie@example.com>", "must be a valid email address"},
+		{"email", "annie", "must be a valid email address"},
+		{"password", "Secret123", ""},
+		{"password", "Sec123", "must be at least 8 characters and contain upper case, lower case and digits"},
+		{"password", "secret123", "must be at least 8 characters and contain upper case, lower case and digits"},
+		{"password=6", "Sec123", ""},
+		{"date", "2000-02-29", ""},
+		{"date", "2001-02-29", "must be a date in YYYY-MM-DD format"},
+		{"date", "29.02.2000", "must be a date in YYYY-MM-DD format"},
+		{"minage=14", age(14), ""},
+		{"minage=14", age(13), "age must be at least 14"},
+		{"maxage=120", age(119), ""},
+		{"maxage=120", age(121), "age must be at most 120"},
+		{"contact", "+7 (999) 123-45-67", ""},
+		{"contact", "@annie", ""},
+		{"contact", "annie@example.com", ""},
+		{"contact", "@ann", "must be a phone number, email address or @username"},
+		{"contact", "call me", "must be a phone number, email address or @username"},
+		{"required,min=3,max=5", "ab", "must be at least 3 characters"},
+	}
+	for _, tt := range tests {
+		rules, err := parseRules(tt.tag)
+		if err != nil {
+			t.Fatalf("parseRules(%q): %v", tt.tag, err)
+		}
+		if got := check(reflect.ValueOf(tt.value), rules); got != tt.want {
+			t.Errorf("%s with %#v = %q, want %q", tt.tag, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseRulesRejects(t *testing.T) {
+	for _, tag := range []string{
+		"unknown",
+		"max=abc",
+		"max=",
+		"max",
+		"min=-1",
+		"minage=1.5",
+		"password=x",
+		"email=1",
+		"required=true",
+	} {
+		if _, err := parseRules(tag); err == nil {
+			t.Errorf("parseRules(%q) succeeded", tag)
+		}
+	}
+}
+
+func TestStruct(t *testing.T) {
+	v := New()
+	if err := v.Struct(input{Title: "Bike", Price: 5}); err != nil {
+		t.Fatalf("valid input: %v", err)
+	}
+
+	err := v.Struct(&input{Title: "Bi", Price: -1})
+	appErr, ok := apperror.As(err)
+	want := []apperror.FieldError{
+		{Field: "title", Message: "must be at least 3 characters"},
+		{Field: "price", Message: "must be at least 1"},
+	}
+	if !ok || appErr.Kind != apperror.KindValidation || !reflect.DeepEqual(appErr.Fields, want) {
+		t.Fatalf("Struct = %v, want field errors %v", err, want)
+	}
+
+	if err := v.Struct("title"); err == nil || apperror.KindOf(err) == apperror.KindValidation {
+		t.Fatalf("Struct of a string = %v, want a programming error", err)
+	}
+}
+
+func TestPartial(t *testing.T) {
+	v := New()
+	if err := v.Partial(input{Price: 5}, "price"); err != nil {
+		t.Fatalf("Partial skipping the missing title: %v", err)
+	}
+
+	appErr, ok := apperror.As(v.Partial(input{Price: 0}, "title"))
+	if !ok || len(appErr.Fields) != 1 || appErr.Fields[0].Field != "title" {
+		t.Fatalf("Partial = %v, want only the title checked", appErr)
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	v := New()
+	v.SetOverrides(Overrides{"input": {"title": "max=3", "note": "required"}})
+
+	appErr, ok := apperror.As(v.Struct(input{Title: "Bike", Price: 1}))
+	want := []apperror.FieldError{
+		{Field: "title", Message: "must be at most 3 characters"},
+		{Field: "note", Message: "is required"},
+	}
+	if !ok || !reflect.DeepEqual(appErr.Fields, want) {
+		t.Fatalf("Struct with overrides = %v, want %v", appErr, want)
+	}
+	if err := v.Struct(input{Title: "Car", Price: 1, Note: "x"}); err != nil {
+		t.Fatalf("override replacing min=3: %v", err)
+	}
+
+	v.SetOverrides(Overrides{"input": {"title": "max=x"}})
+	if err := v.Struct(input{Title: "Car", Price: 1}); err == nil || !strings.Contains(err.Error(), "input.title") {
+		t.Fatalf("Struct with a broken override = %v, want an error naming the field", err)
+	}
+}
@@ -0,0 +1,148 @@
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrOpen = errors.New("circuit breaker is open")
+
+type State int
+
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+type Config struct {
+	// FailureThreshold consecutive failures open the breaker.
+	FailureThreshold int
+	// OpenTimeout is how long the breaker stays open before letting a
+	// single trial call through.
+	OpenTimeout time.Duration
+	// IsFailure decides which errors count against the breaker; nil
+	// counts every error.
+	IsFailure func(error) bool
+	// OnStateChange is called without the lock held on every transition.
+	OnStateChange func(from, to State)
+}
+
+// Breaker stops calling a failing dependency after FailureThreshold
+// consecutive failures and probes it again once OpenTimeout has passed.
+type Breaker struct {
+	cfg Config
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	probing  bool
+	now      func() time.Time
+}
+
+func New(cfg Config) *Breaker {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 5
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = 10 * time.Second
+	}
+	return &Breaker{cfg: cfg, now: time.Now}
+}
+
+// Do calls fn unless the breaker is open, in which case it returns
+// ErrOpen without calling fn.
+func (b *Breaker) Do(fn func() error) error {
+	if err := b.allow(); err != nil {
+		return err
+	}
+	err := fn()
+	b.Report(err)
+	return err
+}
+
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Trip opens the breaker regardless of its state, e.g. when a
+// dependency is known to be down at startup.
+func (b *Breaker) Trip() {
+	b.mu.Lock()
+	from := b.state
+	b.open()
+	b.mu.Unlock()
+	b.changed(from, Open)
+}
+
+// Report records the outcome of a call made outside Do, such as a
+// background health check. A nil error closes the breaker.
+func (b *Breaker) Report(err error) {
+	b.mu.Lock()
+	from := b.state
+	b.probing = false
+	if err != nil && (b.cfg.IsFailure == nil || b.cfg.IsFailure(err)) {
+		b.failures++
+		if b.state == HalfOpen || b.failures >= b.cfg.FailureThreshold {
+			b.open()
+		}
+	} else {
+		b.failures = 0
+		b.state = Closed
+	}
+	to := b.state
+	b.mu.Unlock()
+	b.changed(from, to)
+}
+
+func (b *Breaker) allow() error {
+	b.mu.Lock()
+	from := b.state
+	switch b.state {
+	case Open:
+		if b.now().Sub(b.openedAt) < b.cfg.OpenTimeout {
+			b.mu.Unlock()
+			return ErrOpen
+		}
+		b.state = HalfOpen
+		b.probing = true
+	case HalfOpen:
+		if b.probing {
+			b.mu.Unlock()
+			return ErrOpen
+		}
+		b.probing = true
+	}
+	to := b.state
+	b.mu.Unlock()
+	b.changed(from, to)
+	return nil
+}
+
+func (b *Breaker) open() {
+	b.state = Open
+	b.openedAt = b.now()
+	b.probing = false
+}
+
+func (b *Breaker) changed(from, to State) {
+	if from != to && b.cfg.OnStateChange != nil {
+		b.cfg.OnStateChange(from, to)
+	}
+}
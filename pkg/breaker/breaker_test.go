@@ -0,0 +1,77 @@
+package breaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var errDown = errors.New("down")
+
+func TestBreaker(t *testing.T) {
+	now := time.Unix(0, 0)
+	var transitions []string
+	b := New(Config{
+		FailureThreshold: 2,
+		OpenTimeout:      time.Second,
+		OnStateChange: func(from, to State) {
+			transitions = append(transitions, from.String()+"->"+to.String())
+		},
+	})
+	b.now = func() time.Time { return now }
+
+	fail := func() error { return errDown }
+	ok := func() error { return nil }
+
+	_ = b.Do(fail)
+	if b.State() != Closed {
+		t.Fatalf("state after one failure = %s, want closed", b.State())
+	}
+	_ = b.Do(fail)
+	if b.State() != Open {
+		t.Fatalf("state after threshold = %s, want open", b.State())
+	}
+
+	called := false
+	if err := b.Do(func() error { called = true; return nil }); !errors.Is(err, ErrOpen) || called {
+		t.Fatalf("open breaker returned %v and called=%v", err, called)
+	}
+
+	now = now.Add(time.Second)
+	if err := b.Do(fail); !errors.Is(err, errDown) {
+		t.Fatalf("trial call returned %v, want errDown", err)
+	}
+	if b.State() != Open {
+		t.Fatalf("state after failed trial = %s, want open", b.State())
+	}
+
+	now = now.Add(time.Second)
+	if err := b.Do(ok); err != nil {
+		t.Fatal(err)
+	}
+	if b.State() != Closed {
+		t.Fatalf("state after successful trial = %s, want closed", b.State())
+	}
+
+	want := []string{"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed"}
+	if len(transitions) != len(want) {
+		t.Fatalf("transitions = %v, want %v", transitions, want)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Fatalf("transitions = %v, want %v", transitions, want)
+		}
+	}
+}
+
+func TestBreakerIgnoresNonFailures(t *testing.T) {
+	errMiss := errors.New("miss")
+	b := New(Config{
+		FailureThreshold: 1,
+		IsFailure:        func(err error) bool { return !errors.Is(err, errMiss) },
+	})
+	_ = b.Do(func() error { return errMiss })
+	if b.State() != Closed {
+		t.Fatalf("state = %s, want closed", b.State())
+	}
+}
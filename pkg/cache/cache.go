@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrMiss = errors.New("cache miss")
+
+// Cache stores opaque values by key. Get returns ErrMiss for absent or
+// expired keys. A zero ttl means the entry does not expire.
+//
+// Entries stored with SetWithTags can be removed as a group:
+// InvalidateTags deletes every entry carrying any of the tags and
+// returns the deleted keys.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	SetWithTags(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error
+	Delete(ctx context.Context, keys ...string) error
+	InvalidateTags(ctx context.Context, tags ...string) ([]string, error)
+}
+
+type noop struct{}
+
+// NoOp returns a Cache that stores nothing, for running without cache.
+func NoOp() Cache {
+	return noop{}
+}
+
+func (noop) Get(context.Context, string) ([]byte, error) {
+	return nil, ErrMiss
+}
+
+func (noop) Set(context.Context, string, []byte, time.Duration) error {
+	return nil
+}
+
+func (noop) SetWithTags(context.Context, string, []byte, time.Duration, ...string) error {
+	return nil
+}
+
+func (noop) Delete(context.Context, ...string) error {
+	return nil
+}
+
+func (noop) InvalidateTags(context.Context, ...string) ([]string, error) {
+	return nil, nil
+}
@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(2)
+	now := time.Unix(0, 0)
+	c.now = func() time.Time { return now }
+
+	_ = c.Set(ctx, "a", []byte("1"), 0)
+	_ = c.Set(ctx, "b", []byte("2"), 0)
+	if _, err := c.Get(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	_ = c.Set(ctx, "c", []byte("3"), 0)
+
+	if _, err := c.Get(ctx, "b"); !errors.Is(err, ErrMiss) {
+		t.Fatalf("least recently used key was not evicted: %v", err)
+	}
+	if v, err := c.Get(ctx, "a"); err != nil || string(v) != "1" {
+		t.Fatalf("Get(a) = %q, %v", v, err)
+	}
+
+	_ = c.Set(ctx, "c", []byte("3"), time.Second)
+	now = now.Add(time.Second)
+	if _, err := c.Get(ctx, "c"); !errors.Is(err, ErrMiss) {
+		t.Fatalf("expired key was returned: %v", err)
+	}
+	if c.Len() != 1 {
+		t.Fatalf("Len = %d, want 1", c.Len())
+	}
+
+	_ = c.Delete(ctx, "a", "missing")
+	if _, err := c.Get(ctx, "a"); !errors.Is(err, ErrMiss) {
+		t.Fatalf("deleted key was returned: %v", err)
+	}
+}
+
+func TestLRUTags(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(10)
+
+	_ = c.SetWithTags(ctx, "list:all", []byte("[1,2]"), 0, "ads", "ad:1", "ad:2")
+	_ = c.SetWithTags(ctx, "list:user:7", []byte("[2]"), 0, "user:7", "ad:2")
+	_ = c.Set(ctx, "ad:1", []byte("1"), 0)
+
+	removed, err := c.InvalidateTags(ctx, "ad:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || removed[0] != "list:all" {
+		t.Fatalf("InvalidateTags removed %v, want [list:all]", removed)
+	}
+	if _, err := c.Get(ctx, "list:user:7"); err != nil {
+		t.Fatalf("untagged listing was removed: %v", err)
+	}
+	if _, err := c.Get(ctx, "ad:1"); err != nil {
+		t.Fatalf("entry named like the tag was removed: %v", err)
+	}
+
+	// Overwriting an entry replaces its tags.
+	_ = c.Set(ctx, "list:user:7", []byte("[]"), 0)
+	if removed, _ := c.InvalidateTags(ctx, "ad:2"); len(removed) != 0 {
+		t.Fatalf("stale tag removed %v", removed)
+	}
+}
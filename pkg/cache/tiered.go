@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+// Invalidator broadcasts deleted keys to every instance sharing a
+// remote cache.
+type Invalidator interface {
+	Publish(ctx context.Context, keys []string) error
+	// Listen blocks until ctx is done, calling onKeys for every published
+	// invalidation, including this instance's own, and onReset whenever
+	// invalidations may have been missed, such as after a reconnect.
+	Listen(ctx context.Context, onKeys func(keys []string), onReset func())
+}
+
+// Tiered is a two-level cache: a small in-process L1 in front of a
+// shared L2 such as Redis. Deletes are published through the
+// Invalidator so that other instances drop their L1 copies. L1 entries
+// live at most localTTL, which bounds staleness when invalidations
+// cannot be delivered.
+type Tiered struct {
+	local    *LRU
+	remote   Cache
+	bus      Invalidator
+	localTTL time.Duration
+	// generation changes on every invalidation received, before L1 is
+	// cleared, so that a value fetched from L2 concurrently with an
+	// invalidation is not put in L1.
+	generation atomic.Uint64
+}
+
+func NewTiered(local *LRU, remote Cache, bus Invalidator, localTTL time.Duration) *Tiered {
+	return &Tiered{local: local, remote: remote, bus: bus, localTTL: localTTL}
+}
+
+func (t *Tiered) Get(ctx context.Context, key string) ([]byte, error) {
+	if value, err := t.local.Get(ctx, key); err == nil {
+		return value, nil
+	}
+
+	gen := t.generation.Load()
+	value, err := t.remote.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	// Checking the generation under L1's lock keeps an invalidation from
+	// clearing L1 between the check and the write.
+	t.local.setIf(key, value, t.localTTL, func() bool { return t.generation.Load() == gen })
+	return value, nil
+}
+
+func (t *Tiered) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return t.SetWithTags(ctx, key, value, ttl)
+}
+
+func (t *Tiered) SetWithTags(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error {
+	localTTL := t.localTTL
+	if ttl > 0 && ttl < localTTL {
+		localTTL = ttl
+	}
+	_ = t.local.SetWithTags(ctx, key, value, localTTL, tags...)
+	return t.remote.SetWithTags(ctx, key, value, ttl, tags...)
+}
+
+// Delete removes keys from L2 before L1, so that a concurrent Get cannot
+// copy them back from L2 into L1.
+func (t *Tiered) Delete(ctx context.Context, keys ...string) error {
+	err := t.remote.Delete(ctx, keys...)
+	t.generation.Add(1)
+	_ = t.local.Delete(ctx, keys...)
+	return errors.Join(err, t.bus.Publish(ctx, keys))
+}
+
+// InvalidateTags removes tagged entries from L2 and this instance's L1,
+// then broadcasts the removed keys. Other instances may hold L1 copies
+// fetched from L2 without tags, so they are invalidated by key.
+func (t *Tiered) InvalidateTags(ctx context.Context, tags ...string) ([]string, error) {
+	remoteKeys, err := t.remote.InvalidateTags(ctx, tags...)
+	t.generation.Add(1)
+	localKeys, _ := t.local.InvalidateTags(ctx, tags...)
+
+	keys := slices.Compact(slices.Sorted(slices.Values(append(localKeys, remoteKeys...))))
+	if len(keys) == 0 {
+		return nil, err
+	}
+	return keys, errors.Join(err, t.bus.Publish(ctx, keys))
+}
+
+// Listen applies invalidations from other instances to L1 until ctx is
+// done.
+func (t *Tiered) Listen(ctx context.Context) {
+	t.bus.Listen(ctx,
+		func(keys []string) {
+			t.generation.Add(1)
+			_ = t.local.Delete(ctx, keys...)
+		},
+		func() {
+			t.generation.Add(1)
+			t.local.Purge()
+		},
+	)
+}
@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// bus delivers invalidations synchronously to every listener.
+type bus struct {
+	mu        sync.Mutex
+	listeners []func([]string)
+}
+
+func (b *bus) Publish(_ context.Context, keys []string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, l := range b.listeners {
+		l(keys)
+	}
+	return nil
+}
+
+func (b *bus) Listen(ctx context.Context, onKeys func([]string), onReset func()) {
+	b.mu.Lock()
+	b.listeners = append(b.listeners, onKeys)
+	b.mu.Unlock()
+	onReset()
+}
+
+func TestTieredInvalidatesOtherInstances(t *testing.T) {
+	ctx := context.Background()
+	remote := NewLRU(100)
+	b := &bus{}
+
+	a := NewTiered(NewLRU(100), remote, b, time.Minute)
+	c := NewTiered(NewLRU(100), remote, b, time.Minute)
+	a.Listen(ctx)
+	c.Listen(ctx)
+
+	if err := a.Set(ctx, "ad:1", []byte("v1"), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := c.Get(ctx, "ad:1"); err != nil || string(v) != "v1" {
+		t.Fatalf("Get from second instance = %q, %v", v, err)
+	}
+	if c.local.Len() != 1 {
+		t.Fatal("remote hit was not stored in L1")
+	}
+
+	if err := a.Delete(ctx, "ad:1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(ctx, "ad:1"); !errors.Is(err, ErrMiss) {
+		t.Fatalf("second instance still serves the deleted key: %v", err)
+	}
+}
+
+func TestTieredLocalTTL(t *testing.T) {
+	ctx := context.Background()
+	local := NewLRU(100)
+	now := time.Unix(0, 0)
+	local.now = func() time.Time { return now }
+
+	tc := NewTiered(local, NoOp(), &bus{}, time.Second)
+	_ = tc.Set(ctx, "k", []byte("v"), time.Hour)
+	if _, err := tc.Get(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(time.Second)
+	if _, err := tc.Get(ctx, "k"); !errors.Is(err, ErrMiss) {
+		t.Fatalf("L1 entry outlived localTTL: %v", err)
+	}
+}
+
+// stallingDelete holds Delete calls on the wrapped cache until released.
+type stallingDelete struct {
+	*LRU
+	deleting chan struct{}
+	release  chan struct{}
+}
+
+func (c stallingDelete) Delete(ctx context.Context, keys ...string) error {
+	close(c.deleting)
+	<-c.release
+	return c.LRU.Delete(ctx, keys...)
+}
+
+func TestTieredGetDuringDelete(t *testing.T) {
+	ctx := context.Background()
+	remote := stallingDelete{LRU: NewLRU(100), deleting: make(chan struct{}), release: make(chan struct{})}
+	_ = remote.Set(ctx, "k", []byte("v"), time.Hour)
+	tc := NewTiered(NewLRU(100), remote, &bus{}, time.Minute)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = tc.Delete(ctx, "k")
+	}()
+	<-remote.deleting
+	// L2 still holds the key while the delete is in flight.
+	if _, err := tc.Get(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	close(remote.release)
+	<-done
+
+	if v, err := tc.Get(ctx, "k"); !errors.Is(err, ErrMiss) {
+		t.Fatalf("Get after Delete = %q, %v; want a miss", v, err)
+	}
+}
@@ -0,0 +1,109 @@
+// Package httpcache implements HTTP validators and conditional requests
+// (RFC 9110, section 13).
+package httpcache
+
+import (
+	"encoding/hex"
+	"hash/fnv"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Validators describe the current representation of a resource. Either
+// field may be empty.
+type Validators struct {
+	ETag         string
+	LastModified time.Time
+}
+
+// ETag quotes value as a strong entity tag.
+func ETag(value string) string {
+	return `"` + value + `"`
+}
+
+// WeakETag derives a weak entity tag from parts, for representations
+// such as listings that have no version of their own.
+func WeakETag(parts ...string) string {
+	h := fnv.New64a()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return `W/"` + hex.EncodeToString(h.Sum(nil)) + `"`
+}
+
+// NotModified sets the validators and cacheControl on w and reports
+// whether the conditional headers of a GET or HEAD request show that
+// the client's copy is current. In that case it has already answered
+// 304 Not Modified and the caller must not write a body.
+func NotModified(w http.ResponseWriter, r *http.Request, v Validators, cacheControl string) bool {
+	SetValidators(w, v)
+	if cacheControl != "" {
+		w.Header().Set("Cache-Control", cacheControl)
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if !matchWeak(inm, v.ETag) {
+			return false
+		}
+	} else if ims := r.Header.Get("If-Modified-Since"); ims != "" && !v.LastModified.IsZero() {
+		since, err := http.ParseTime(ims)
+		if err != nil || v.LastModified.Truncate(time.Second).After(since) {
+			return false
+		}
+	} else {
+		return false
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// SetValidators sets the ETag and Last-Modified headers, e.g. on the
+// response to a successful write.
+func SetValidators(w http.ResponseWriter, v Validators) {
+	if v.ETag != "" {
+		w.Header().Set("ETag", v.ETag)
+	}
+	if !v.LastModified.IsZero() {
+		w.Header().Set("Last-Modified", v.LastModified.UTC().Format(http.TimeFormat))
+	}
+}
+
+// IfMatch returns the single strong entity tag in the If-Match header,
+// "*" for a wildcard, or "" when the header is absent. ok is false when
+// the header holds a list or a weak tag, which cannot identify one
+// version to write against.
+func IfMatch(r *http.Request) (etag string, ok bool) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return value, true
+	}
+	if strings.Contains(value, ",") || strings.HasPrefix(value, "W/") {
+		return "", false
+	}
+	return value, true
+}
+
+// matchWeak reports whether etag is in the If-None-Match list, using
+// the weak comparison function.
+func matchWeak(list, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	if strings.TrimSpace(list) == "*" {
+		return true
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(list, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package httpcache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNotModified(t *testing.T) {
+	modified := time.Date(2024, time.March, 1, 12, 0, 0, 500, time.UTC)
+	v := Validators{ETag: ETag("ad-1-v2"), LastModified: modified}
+
+	tests := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		want    bool
+	}{
+		{"no conditions", http.MethodGet, nil, false},
+		{"matching etag", http.MethodGet, map[string]string{"If-None-Match": `"ad-1-v2"`}, true},
+		{"weak match", http.MethodGet, map[string]string{"If-None-Match": `"x", W/"ad-1-v2"`}, true},
+		{"wildcard", http.MethodGet, map[string]string{"If-None-Match": "*"}, true},
+		{"stale etag", http.MethodGet, map[string]string{"If-None-Match": `"ad-1-v1"`}, false},
+		{"etag wins over date", http.MethodGet, map[string]string{"If-None-Match": `"ad-1-v1"`, "If-Modified-Since": modified.Add(time.Hour).Format(http.TimeFormat)}, false},
+		{"not modified since", http.MethodGet, map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)}, true},
+		{"modified since", http.MethodGet, map[string]string{"If-Modified-Since": modified.Add(-time.Second).Format(http.TimeFormat)}, false},
+		{"invalid date", http.MethodGet, map[string]string{"If-Modified-Since": "yesterday"}, false},
+		{"write method", http.MethodPut, map[string]string{"If-None-Match": `"ad-1-v2"`}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/ads/1", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+
+			if got := NotModified(w, r, v, "no-cache"); got != tt.want {
+				t.Fatalf("NotModified = %v, want %v", got, tt.want)
+			}
+			if tt.want && w.Code != http.StatusNotModified {
+				t.Fatalf("status = %d, want 304", w.Code)
+			}
+			if w.Header().Get("ETag") != `"ad-1-v2"` || w.Header().Get("Cache-Control") != "no-cache" {
+				t.Fatalf("headers = %v", w.Header())
+			}
+			if w.Header().Get("Last-Modified") != "Fri, 01 Mar 2024 12:00:00 GMT" {
+				t.Fatalf("Last-Modified = %q", w.Header().Get("Last-Modified"))
+			}
+		})
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	tests := map[string]struct {
+		etag string
+		ok   bool
+	}{
+		"":            {"", true},
+		"*":           {"*", true},
+		`"ad-1-v2"`:   {`"ad-1-v2"`, true},
+		`W/"ad-1-v2"`: {"", false},
+		`"a", "b"`:    {"", false},
+	}
+	for header, want := range tests {
+		r := httptest.NewRequest(http.MethodPut, "/ads/1", nil)
+		if header != "" {
+			r.Header.Set("If-Match", header)
+		}
+		etag, ok := IfMatch(r)
+		if etag != want.etag || ok != want.ok {
+			t.Errorf("IfMatch(%q) = %q, %v, want %q, %v", header, etag, ok, want.etag, want.ok)
+		}
+	}
+}
+
+func TestWeakETag(t *testing.T) {
+	a := WeakETag("ad-1-v1", "ad-2-v1")
+	if a != WeakETag("ad-1-v1", "ad-2-v1") {
+		t.Fatal("WeakETag is not deterministic")
+	}
+	if a == WeakETag("ad-1-v1", "ad-2-v2") || a == WeakETag("ad-1-v1") {
+		t.Fatal("WeakETag did not change with its parts")
+	}
+}
@@ -0,0 +1,675 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        (unknown)
+// source: bulletin/v1/ad.proto
+
+package bulletinv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Ad struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Price         int64                  `protobuf:"varint,4,opt,name=price,proto3" json:"price,omitempty"`
+	UserId        int64                  `protobuf:"varint,5,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Ad) Reset() {
+	*x = Ad{}
+	mi := &file_bulletin_v1_ad_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Ad) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Ad) ProtoMessage() {}
+
+func (x *Ad) ProtoReflect() protoreflect.Message {
+	mi := &file_bulletin_v1_ad_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Ad.ProtoReflect.Descriptor instead.
+func (*Ad) Descriptor() ([]byte, []int) {
+	return file_bulletin_v1_ad_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Ad) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Ad) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Ad) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Ad) GetPrice() int64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *Ad) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+type ListAdsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAdsRequest) Reset() {
+	*x = ListAdsRequest{}
+	mi := &file_bulletin_v1_ad_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAdsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAdsRequest) ProtoMessage() {}
+
+func (x *ListAdsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bulletin_v1_ad_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAdsRequest.ProtoReflect.Descriptor instead.
+func (*ListAdsRequest) Descriptor() ([]byte, []int) {
+	return file_bulletin_v1_ad_proto_rawDescGZIP(), []int{1}
+}
+
+type ListAdsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ads           []*Ad                  `protobuf:"bytes,1,rep,name=ads,proto3" json:"ads,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAdsResponse) Reset() {
+	*x = ListAdsResponse{}
+	mi := &file_bulletin_v1_ad_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAdsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAdsResponse) ProtoMessage() {}
+
+func (x *ListAdsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bulletin_v1_ad_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAdsResponse.ProtoReflect.Descriptor instead.
+func (*ListAdsResponse) Descriptor() ([]byte, []int) {
+	return file_bulletin_v1_ad_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListAdsResponse) GetAds() []*Ad {
+	if x != nil {
+		return x.Ads
+	}
+	return nil
+}
+
+type GetAdRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAdRequest) Reset() {
+	*x = GetAdRequest{}
+	mi := &file_bulletin_v1_ad_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAdRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAdRequest) ProtoMessage() {}
+
+func (x *GetAdRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bulletin_v1_ad_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAdRequest.ProtoReflect.Descriptor instead.
+func (*GetAdRequest) Descriptor() ([]byte, []int) {
+	return file_bulletin_v1_ad_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetAdRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type GetAdResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ad            *Ad                    `protobuf:"bytes,1,opt,name=ad,proto3" json:"ad,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAdResponse) Reset() {
+	*x = GetAdResponse{}
+	mi := &file_bulletin_v1_ad_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAdResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAdResponse) ProtoMessage() {}
+
+func (x *GetAdResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bulletin_v1_ad_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAdResponse.ProtoReflect.Descriptor instead.
+func (*GetAdResponse) Descriptor() ([]byte, []int) {
+	return file_bulletin_v1_ad_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetAdResponse) GetAd() *Ad {
+	if x != nil {
+		return x.Ad
+	}
+	return nil
+}
+
+type CreateAdRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Title         string                 `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
+	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Price         int64                  `protobuf:"varint,3,opt,name=price,proto3" json:"price,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateAdRequest) Reset() {
+	*x = CreateAdRequest{}
+	mi := &file_bulletin_v1_ad_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateAdRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateAdRequest) ProtoMessage() {}
+
+func (x *CreateAdRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bulletin_v1_ad_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateAdRequest.ProtoReflect.Descriptor instead.
+func (*CreateAdRequest) Descriptor() ([]byte, []int) {
+	return file_bulletin_v1_ad_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CreateAdRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *CreateAdRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *CreateAdRequest) GetPrice() int64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+type CreateAdResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ad            *Ad                    `protobuf:"bytes,1,opt,name=ad,proto3" json:"ad,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateAdResponse) Reset() {
+	*x = CreateAdResponse{}
+	mi := &file_bulletin_v1_ad_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateAdResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateAdResponse) ProtoMessage() {}
+
+func (x *CreateAdResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bulletin_v1_ad_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateAdResponse.ProtoReflect.Descriptor instead.
+func (*CreateAdResponse) Descriptor() ([]byte, []int) {
+	return file_bulletin_v1_ad_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CreateAdResponse) GetAd() *Ad {
+	if x != nil {
+		return x.Ad
+	}
+	return nil
+}
+
+type UpdateAdRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Price         int64                  `protobuf:"varint,4,opt,name=price,proto3" json:"price,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateAdRequest) Reset() {
+	*x = UpdateAdRequest{}
+	mi := &file_bulletin_v1_ad_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateAdRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateAdRequest) ProtoMessage() {}
+
+func (x *UpdateAdRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bulletin_v1_ad_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateAdRequest.ProtoReflect.Descriptor instead.
+func (*UpdateAdRequest) Descriptor() ([]byte, []int) {
+	return file_bulletin_v1_ad_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *UpdateAdRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateAdRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *UpdateAdRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *UpdateAdRequest) GetPrice() int64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+type UpdateAdResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ad            *Ad                    `protobuf:"bytes,1,opt,name=ad,proto3" json:"ad,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateAdResponse) Reset() {
+	*x = UpdateAdResponse{}
+	mi := &file_bulletin_v1_ad_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateAdResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateAdResponse) ProtoMessage() {}
+
+func (x *UpdateAdResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bulletin_v1_ad_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateAdResponse.ProtoReflect.Descriptor instead.
+func (*UpdateAdResponse) Descriptor() ([]byte, []int) {
+	return file_bulletin_v1_ad_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *UpdateAdResponse) GetAd() *Ad {
+	if x != nil {
+		return x.Ad
+	}
+	return nil
+}
+
+type DeleteAdRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteAdRequest) Reset() {
+	*x = DeleteAdRequest{}
+	mi := &file_bulletin_v1_ad_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteAdRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteAdRequest) ProtoMessage() {}
+
+func (x *DeleteAdRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bulletin_v1_ad_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteAdRequest.ProtoReflect.Descriptor instead.
+func (*DeleteAdRequest) Descriptor() ([]byte, []int) {
+	return file_bulletin_v1_ad_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DeleteAdRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type DeleteAdResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteAdResponse) Reset() {
+	*x = DeleteAdResponse{}
+	mi := &file_bulletin_v1_ad_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteAdResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteAdResponse) ProtoMessage() {}
+
+func (x *DeleteAdResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bulletin_v1_ad_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteAdResponse.ProtoReflect.Descriptor instead.
+func (*DeleteAdResponse) Descriptor() ([]byte, []int) {
+	return file_bulletin_v1_ad_proto_rawDescGZIP(), []int{10}
+}
+
+var File_bulletin_v1_ad_proto protoreflect.FileDescriptor
+
+const file_bulletin_v1_ad_proto_rawDesc = "" +
+	"\n" +
+	"\x14bulletin/v1/ad.proto\x12\vbulletin.v1\"{\n" +
+	"\x02Ad\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x14\n" +
+	"\x05price\x18\x04 \x01(\x03R\x05price\x12\x17\n" +
+	"\auser_id\x18\x05 \x01(\x03R\x06userId\"\x10\n" +
+	"\x0eListAdsRequest\"4\n" +
+	"\x0fListAdsResponse\x12!\n" +
+	"\x03ads\x18\x01 \x03(\v2\x0f.bulletin.v1.AdR\x03ads\"\x1e\n" +
+	"\fGetAdRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"0\n" +
+	"\rGetAdResponse\x12\x1f\n" +
+	"\x02ad\x18\x01 \x01(\v2\x0f.bulletin.v1.AdR\x02ad\"_\n" +
+	"\x0fCreateAdRequest\x12\x14\n" +
+	"\x05title\x18\x01 \x01(\tR\x05title\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12\x14\n" +
+	"\x05price\x18\x03 \x01(\x03R\x05price\"3\n" +
+	"\x10CreateAdResponse\x12\x1f\n" +
+	"\x02ad\x18\x01 \x01(\v2\x0f.bulletin.v1.AdR\x02ad\"o\n" +
+	"\x0fUpdateAdRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x14\n" +
+	"\x05price\x18\x04 \x01(\x03R\x05price\"3\n" +
+	"\x10UpdateAdResponse\x12\x1f\n" +
+	"\x02ad\x18\x01 \x01(\v2\x0f.bulletin.v1.AdR\x02ad\"!\n" +
+	"\x0fDeleteAdRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"\x12\n" +
+	"\x10DeleteAdResponse2\xec\x02\n" +
+	"\tAdService\x12D\n" +
+	"\aListAds\x12\x1b.bulletin.v1.ListAdsRequest\x1a\x1c.bulletin.v1.ListAdsResponse\x12>\n" +
+	"\x05GetAd\x12\x19.bulletin.v1.GetAdRequest\x1a\x1a.bulletin.v1.GetAdResponse\x12G\n" +
+	"\bCreateAd\x12\x1c.bulletin.v1.CreateAdRequest\x1a\x1d.bulletin.v1.CreateAdResponse\x12G\n" +
+	"\bUpdateAd\x12\x1c.bulletin.v1.UpdateAdRequest\x1a\x1d.bulletin.v1.UpdateAdResponse\x12G\n" +
+	"\bDeleteAd\x12\x1c.bulletin.v1.DeleteAdRequest\x1a\x1d.bulletin.v1.DeleteAdResponseB.Z,bulletin-board/pkg/pb/bulletin/v1;bulletinv1b\x06proto3"
+
+var (
+	file_bulletin_v1_ad_proto_rawDescOnce sync.Once
+	file_bulletin_v1_ad_proto_rawDescData []byte
+)
+
+func file_bulletin_v1_ad_proto_rawDescGZIP() []byte {
+	file_bulletin_v1_ad_proto_rawDescOnce.Do(func() {
+		file_bulletin_v1_ad_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_bulletin_v1_ad_proto_rawDesc), len(file_bulletin_v1_ad_proto_rawDesc)))
+	})
+	return file_bulletin_v1_ad_proto_rawDescData
+}
+
+var file_bulletin_v1_ad_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_bulletin_v1_ad_proto_goTypes = []any{
+	(*Ad)(nil),               // 0: bulletin.v1.Ad
+	(*ListAdsRequest)(nil),   // 1: bulletin.v1.ListAdsRequest
+	(*ListAdsResponse)(nil),  // 2: bulletin.v1.ListAdsResponse
+	(*GetAdRequest)(nil),     // 3: bulletin.v1.GetAdRequest
+	(*GetAdResponse)(nil),    // 4: bulletin.v1.GetAdResponse
+	(*CreateAdRequest)(nil),  // 5: bulletin.v1.CreateAdRequest
+	(*CreateAdResponse)(nil), // 6: bulletin.v1.CreateAdResponse
+	(*UpdateAdRequest)(nil),  // 7: bulletin.v1.UpdateAdRequest
+	(*UpdateAdResponse)(nil), // 8: bulletin.v1.UpdateAdResponse
+	(*DeleteAdRequest)(nil),  // 9: bulletin.v1.DeleteAdRequest
+	(*DeleteAdResponse)(nil), // 10: bulletin.v1.DeleteAdResponse
+}
+var file_bulletin_v1_ad_proto_depIdxs = []int32{
+	0,  // 0: bulletin.v1.ListAdsResponse.ads:type_name -> bulletin.v1.Ad
+	0,  // 1: bulletin.v1.GetAdResponse.ad:type_name -> bulletin.v1.Ad
+	0,  // 2: bulletin.v1.CreateAdResponse.ad:type_name -> bulletin.v1.Ad
+	0,  // 3: bulletin.v1.UpdateAdResponse.ad:type_name -> bulletin.v1.Ad
+	1,  // 4: bulletin.v1.AdService.ListAds:input_type -> bulletin.v1.ListAdsRequest
+	3,  // 5: bulletin.v1.AdService.GetAd:input_type -> bulletin.v1.GetAdRequest
+	5,  // 6: bulletin.v1.AdService.CreateAd:input_type -> bulletin.v1.CreateAdRequest
+	7,  // 7: bulletin.v1.AdService.UpdateAd:input_type -> bulletin.v1.UpdateAdRequest
+	9,  // 8: bulletin.v1.AdService.DeleteAd:input_type -> bulletin.v1.DeleteAdRequest
+	2,  // 9: bulletin.v1.AdService.ListAds:output_type -> bulletin.v1.ListAdsResponse
+	4,  // 10: bulletin.v1.AdService.GetAd:output_type -> bulletin.v1.GetAdResponse
+	6,  // 11: bulletin.v1.AdService.CreateAd:output_type -> bulletin.v1.CreateAdResponse
+	8,  // 12: bulletin.v1.AdService.UpdateAd:output_type -> bulletin.v1.UpdateAdResponse
+	10, // 13: bulletin.v1.AdService.DeleteAd:output_type -> bulletin.v1.DeleteAdResponse
+	9,  // [9:14] is the sub-list for method output_type
+	4,  // [4:9] is the sub-list for method input_type
+	4,  // [4:4] is the sub-list for extension type_name
+	4,  // [4:4] is the sub-list for extension extendee
+	0,  // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_bulletin_v1_ad_proto_init() }
+func file_bulletin_v1_ad_proto_init() {
+	if File_bulletin_v1_ad_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_bulletin_v1_ad_proto_rawDesc), len(file_bulletin_v1_ad_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_bulletin_v1_ad_proto_goTypes,
+		DependencyIndexes: file_bulletin_v1_ad_proto_depIdxs,
+		MessageInfos:      file_bulletin_v1_ad_proto_msgTypes,
+	}.Build()
+	File_bulletin_v1_ad_proto = out.File
+	file_bulletin_v1_ad_proto_goTypes = nil
+	file_bulletin_v1_ad_proto_depIdxs = nil
+}
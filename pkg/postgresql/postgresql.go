@@ -1,13 +1,14 @@
 package postgresql
 
 import (
-	"bulletin-board/pkg/utils"
+	"bulletin-board/pkg/retry"
 	"context"
+	"errors"
 	"fmt"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"log"
+	"strings"
 	"time"
 )
 
@@ -22,23 +23,36 @@ type Client interface {
 	Begin(ctx context.Context) (pgx.Tx, error)
 }
 
-func NewClient(ctx context.Context, pc PostgresConfig) (pool *pgxpool.Pool, err error) {
+// NewClient opens a pool and pings the server, retrying with backoff
+// while it is unreachable. Authentication and unknown database errors
+// fail immediately.
+func NewClient(ctx context.Context, pc PostgresConfig) (*pgxpool.Pool, error) {
 	dsn := fmt.Sprintf("postgresql://%s:%s@%s:%s/%s", pc.Username, pc.Password, pc.Host, pc.Port, pc.Database)
-	err = utils.DoWithTries(func() error {
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("connect to postgresql: %w", err)
+	}
+
+	policy := retry.Default()
+	policy.Retryable = isRetryableConnect
+	err = retry.Do(ctx, policy, func(ctx context.Context) error {
 		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 		defer cancel()
-
-		pool, err = pgxpool.New(ctx, dsn)
-		if err != nil {
-			return err
-		}
-
-		return nil
-	}, 3, 5*time.Second)
-
+		return pool.Ping(ctx)
+	})
 	if err != nil {
-		log.Fatal("error do with tries postgresql")
+		pool.Close()
+		return nil, fmt.Errorf("connect to postgresql: %w", err)
 	}
 
-	return pool, err
+	return pool, nil
+}
+
+func isRetryableConnect(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return true
+	}
+	// Class 28 is invalid authorization, 3D000 an unknown database.
+	return !strings.HasPrefix(pgErr.Code, "28") && pgErr.Code != "3D000"
 }
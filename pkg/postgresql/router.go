@@ -0,0 +1,173 @@
+package postgresql
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const healthCheckTimeout = 2 * time.Second
+
+// Router is a Client that sends writes and transactions to the primary
+// and spreads read-only queries over healthy replicas. Once a session
+// (see WithSession) has written, its reads stay on the primary so that
+// it sees its own writes despite replication lag. Reads under
+// WithPrimary always go to the primary.
+type Router struct {
+	primary  *pgxpool.Pool
+	replicas []*replica
+	next     atomic.Uint64
+}
+
+type replica struct {
+	pool    *pgxpool.Pool
+	healthy atomic.Bool
+}
+
+func NewRouter(primary *pgxpool.Pool, replicas ...*pgxpool.Pool) *Router {
+	r := &Router{primary: primary}
+	for _, pool := range replicas {
+		rep := &replica{pool: pool}
+		rep.healthy.Store(true)
+		r.replicas = append(r.replicas, rep)
+	}
+	return r
+}
+
+func (r *Router) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	markWrite(ctx)
+	return r.primary.Exec(ctx, sql, arguments...)
+}
+
+func (r *Router) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return r.reader(ctx, sql).Query(ctx, sql, args...)
+}
+
+func (r *Router) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return r.reader(ctx, sql).QueryRow(ctx, sql, args...)
+}
+
+func (r *Router) Begin(ctx context.Context) (pgx.Tx, error) {
+	markWrite(ctx)
+	return r.primary.Begin(ctx)
+}
+
+// HealthCheck pings every replica each interval until ctx is done.
+// Unreachable replicas get no reads until they answer again.
+func (r *Router) HealthCheck(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r.checkReplicas(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Router) Close() {
+	for _, rep := range r.replicas {
+		rep.pool.Close()
+	}
+	r.primary.Close()
+}
+
+func (r *Router) checkReplicas(ctx context.Context) {
+	for i, rep := range r.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+		err := rep.pool.Ping(pingCtx)
+		cancel()
+
+		healthy := err == nil
+		if rep.healthy.Swap(healthy) != healthy {
+			if healthy {
+				slog.InfoContext(ctx, "postgres replica is back", slog.Int("replica", i))
+			} else {
+				slog.WarnContext(ctx, "postgres replica is down", slog.Int("replica", i), slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// reader picks the pool for a query: the primary for statements that
+// may write or for sessions that already wrote, otherwise the next
+// healthy replica, falling back to the primary when there is none.
+func (r *Router) reader(ctx context.Context, sql string) Client {
+	if !isReadOnly(sql) {
+		markWrite(ctx)
+		return r.primary
+	}
+	if hasWritten(ctx) || readsPrimary(ctx) || len(r.replicas) == 0 {
+		return r.primary
+	}
+
+	start := r.next.Add(1)
+	for i := range r.replicas {
+		rep := r.replicas[(start+uint64(i))%uint64(len(r.replicas))]
+		if rep.healthy.Load() {
+			return rep.pool
+		}
+	}
+	return r.primary
+}
+
+// isReadOnly reports whether sql is a plain select. Anything else,
+// including CTEs that may modify data and locking selects, is treated
+// as a write.
+func isReadOnly(sql string) bool {
+	q := strings.ToLower(strings.TrimSpace(sql))
+	if !strings.HasPrefix(q, "select") {
+		return false
+	}
+	for _, lock := range []string{"for update", "for no key update", "for share", "for key share"} {
+		if strings.Contains(q, lock) {
+			return false
+		}
+	}
+	return true
+}
+
+type session struct {
+	wrote atomic.Bool
+}
+
+type sessionKey struct{}
+
+// WithSession starts a read-your-writes session, typically one per
+// request. Without a session every read may go to a replica.
+func WithSession(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sessionKey{}, &session{})
+}
+
+func markWrite(ctx context.Context) {
+	if s, ok := ctx.Value(sessionKey{}).(*session); ok {
+		s.wrote.Store(true)
+	}
+}
+
+func hasWritten(ctx context.Context) bool {
+	s, ok := ctx.Value(sessionKey{}).(*session)
+	return ok && s.wrote.Load()
+}
+
+type primaryKey struct{}
+
+// WithPrimary sends every read under ctx to the primary. It is meant for
+// reads whose results outlive the request, such as cache fills, which
+// must not keep a lagging replica's view around.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+func readsPrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryKey{}).(bool)
+	return primary
+}
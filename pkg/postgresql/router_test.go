@@ -0,0 +1,78 @@
+package postgresql
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"testing"
+)
+
+func newPool(t *testing.T, host string) *pgxpool.Pool {
+	t.Helper()
+	// pgxpool connects lazily, so no server is needed to test routing.
+	pool, err := pgxpool.New(context.Background(), "postgresql://u:p@"+host+":5432/db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+func TestIsReadOnly(t *testing.T) {
+	tests := map[string]bool{
+		"select id from ads":                                true,
+		"\n\t\tSELECT id from ads where id = $1":            true,
+		"select id from ads where id = $1 for update":       false,
+		"insert into ads (title) values ($1) returning id":  false,
+		"update ads set title = $1 returning id":            false,
+		"with d as (delete from ads returning id) select 1": false,
+	}
+	for sql, want := range tests {
+		if got := isReadOnly(sql); got != want {
+			t.Errorf("isReadOnly(%q) = %v, want %v", sql, got, want)
+		}
+	}
+}
+
+func TestRouterReader(t *testing.T) {
+	primary := newPool(t, "primary")
+	replica1 := newPool(t, "replica1")
+	replica2 := newPool(t, "replica2")
+	r := NewRouter(primary, replica1, replica2)
+
+	ctx := WithSession(context.Background())
+	const read = "select id from ads"
+
+	seen := map[Client]bool{}
+	for i := 0; i < 4; i++ {
+		seen[r.reader(ctx, read)] = true
+	}
+	if !seen[replica1] || !seen[replica2] || seen[primary] {
+		t.Fatalf("reads were not spread over replicas: %v", seen)
+	}
+
+	r.replicas[0].healthy.Store(false)
+	for i := 0; i < 4; i++ {
+		if got := r.reader(ctx, read); got != replica2 {
+			t.Fatalf("read went to %v, want the healthy replica", got)
+		}
+	}
+
+	r.replicas[1].healthy.Store(false)
+	if got := r.reader(ctx, read); got != primary {
+		t.Fatal("read did not fall back to the primary")
+	}
+
+	r.replicas[0].healthy.Store(true)
+	if got := r.reader(ctx, "insert into ads (title) values ($1) returning id"); got != primary {
+		t.Fatal("write did not go to the primary")
+	}
+	if got := r.reader(ctx, read); got != primary {
+		t.Fatal("read after write in the same session did not stick to the primary")
+	}
+	if got := r.reader(context.Background(), read); got != replica1 {
+		t.Fatal("read without a session did not go to a replica")
+	}
+	if got := r.reader(WithPrimary(context.Background()), read); got != primary {
+		t.Fatal("read under WithPrimary did not go to the primary")
+	}
+}
@@ -0,0 +1,80 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+
+	defaultTxAttempts = 3
+)
+
+type txKey struct{}
+
+// Conn returns the transaction carried by ctx, or client when the call
+// is not part of a unit of work. Repositories use it for every query so
+// that they join a transaction started by Transactor.Do.
+func Conn(ctx context.Context, client Client) Client {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return client
+}
+
+// Transactor runs units of work in serializable transactions.
+type Transactor struct {
+	client   Client
+	attempts int
+}
+
+func NewTransactor(client Client) *Transactor {
+	return &Transactor{client: client, attempts: defaultTxAttempts}
+}
+
+// Do runs fn in a transaction stored in the context passed to fn. The
+// transaction is committed when fn returns nil and rolled back
+// otherwise. Serialization failures and deadlocks restart fn from the
+// beginning, so fn must not have side effects outside the database.
+// Nested calls join the outer transaction.
+func (t *Transactor) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+
+	var err error
+	for attempt := 0; attempt < t.attempts; attempt++ {
+		err = t.run(ctx, fn)
+		if !IsSerializationFailure(err) {
+			return err
+		}
+	}
+	return fmt.Errorf("transaction failed after %d attempts: %w", t.attempts, err)
+}
+
+func (t *Transactor) run(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	tx, err := t.client.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(context.WithoutCancel(ctx))
+		}
+	}()
+
+	if _, err = tx.Exec(ctx, "set transaction isolation level serializable"); err != nil {
+		return err
+	}
+	if err = fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func IsSerializationFailure(err error) bool {
+	return hasCode(err, serializationFailure) || hasCode(err, deadlockDetected)
+}
@@ -0,0 +1,115 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// Policy describes how often and how long an operation is retried. The
+// wait before attempt n+1 is InitialInterval*Multiplier^(n-1), capped
+// at MaxInterval and randomized by ±Jitter of itself.
+type Policy struct {
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	Multiplier      float64
+	Jitter          float64
+	// MaxElapsedTime bounds the total time spent retrying; zero means no
+	// bound. MaxAttempts bounds the number of calls; zero means no bound.
+	MaxElapsedTime time.Duration
+	MaxAttempts    int
+	// Retryable reports whether an error is worth another attempt. When
+	// nil every error except Permanent ones is retried.
+	Retryable func(error) bool
+}
+
+func Default() Policy {
+	return Policy{
+		InitialInterval: 200 * time.Millisecond,
+		MaxInterval:     5 * time.Second,
+		Multiplier:      2,
+		Jitter:          0.5,
+		MaxElapsedTime:  30 * time.Second,
+	}
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not retryable. Do returns the unwrapped error.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Do calls fn until it succeeds, returns a non-retryable error, or the
+// policy or ctx gives up. On give-up the last error of fn is returned.
+func Do(ctx context.Context, p Policy, fn func(ctx context.Context) error) error {
+	start := time.Now()
+	interval := p.InitialInterval
+
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+
+		var perm *permanentError
+		if errors.As(err, &perm) {
+			return perm.err
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		if p.Retryable != nil && !p.Retryable(err) {
+			return err
+		}
+		if p.MaxAttempts > 0 && attempt >= p.MaxAttempts {
+			return fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+
+		wait := jitter(interval, p.Jitter)
+		if p.MaxElapsedTime > 0 && time.Since(start)+wait > p.MaxElapsedTime {
+			return fmt.Errorf("giving up after %s: %w", time.Since(start).Round(time.Millisecond), err)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		interval = next(interval, p)
+	}
+}
+
+func next(interval time.Duration, p Policy) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	interval = time.Duration(float64(interval) * multiplier)
+	if p.MaxInterval > 0 && interval > p.MaxInterval {
+		interval = p.MaxInterval
+	}
+	return interval
+}
+
+func jitter(interval time.Duration, factor float64) time.Duration {
+	if factor <= 0 || interval <= 0 {
+		return interval
+	}
+	delta := factor * float64(interval)
+	return time.Duration(float64(interval) - delta + rand.Float64()*2*delta)
+}
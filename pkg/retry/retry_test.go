@@ -0,0 +1,108 @@
+package retry_test
+
+import (
+	"bulletin-board/pkg/retry"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errTemporary = errors.New("temporary")
+
+func fastPolicy() retry.Policy {
+	return retry.Policy{
+		InitialInterval: time.Millisecond,
+		MaxInterval:     5 * time.Millisecond,
+		Multiplier:      2,
+		Jitter:          0.5,
+	}
+}
+
+func TestDoRetriesUntilSuccess(t *testing.T) {
+	calls := 0
+	err := retry.Do(context.Background(), fastPolicy(), func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return errTemporary
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("Do = %v after %d calls, want nil after 3", err, calls)
+	}
+}
+
+func TestDoMaxAttempts(t *testing.T) {
+	p := fastPolicy()
+	p.MaxAttempts = 4
+	calls := 0
+	err := retry.Do(context.Background(), p, func(context.Context) error {
+		calls++
+		return errTemporary
+	})
+	if !errors.Is(err, errTemporary) || calls != 4 {
+		t.Fatalf("Do = %v after %d calls, want errTemporary after 4", err, calls)
+	}
+}
+
+func TestDoStopsOnPermanentAndUnretryable(t *testing.T) {
+	errFatal := errors.New("fatal")
+
+	calls := 0
+	err := retry.Do(context.Background(), fastPolicy(), func(context.Context) error {
+		calls++
+		return retry.Permanent(errFatal)
+	})
+	if err != errFatal || calls != 1 {
+		t.Fatalf("Do = %v after %d calls, want errFatal after 1", err, calls)
+	}
+
+	p := fastPolicy()
+	p.Retryable = func(err error) bool { return !errors.Is(err, errFatal) }
+	calls = 0
+	err = retry.Do(context.Background(), p, func(context.Context) error {
+		calls++
+		return errFatal
+	})
+	if err != errFatal || calls != 1 {
+		t.Fatalf("Do = %v after %d calls, want errFatal after 1", err, calls)
+	}
+}
+
+func TestDoMaxElapsedTime(t *testing.T) {
+	p := fastPolicy()
+	p.MaxElapsedTime = 20 * time.Millisecond
+	start := time.Now()
+	err := retry.Do(context.Background(), p, func(context.Context) error {
+		return errTemporary
+	})
+	if !errors.Is(err, errTemporary) {
+		t.Fatalf("Do = %v, want errTemporary", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Do ran for %s despite MaxElapsedTime", elapsed)
+	}
+}
+
+func TestDoHonoursContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := fastPolicy()
+	p.InitialInterval = time.Hour
+	p.MaxInterval = time.Hour
+
+	done := make(chan error)
+	go func() {
+		done <- retry.Do(ctx, p, func(context.Context) error { return errTemporary })
+	}()
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, errTemporary) {
+			t.Fatalf("Do = %v, want errTemporary", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Do did not return after the context was cancelled")
+	}
+}
@@ -0,0 +1,45 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Timestamps are stored as RFC 3339 text in UTC.
+const timeLayout = time.RFC3339Nano
+
+func FormatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+// ScanTime returns a Scanner that parses a timestamp column into t.
+func ScanTime(t *time.Time) sql.Scanner {
+	return timeScanner{t: t}
+}
+
+type timeScanner struct {
+	t *time.Time
+}
+
+func (s timeScanner) Scan(src any) error {
+	var text string
+	switch v := src.(type) {
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	case time.Time:
+		*s.t = v.UTC()
+		return nil
+	default:
+		return fmt.Errorf("scan timestamp: unsupported type %T", src)
+	}
+
+	parsed, err := time.Parse(timeLayout, text)
+	if err != nil {
+		return fmt.Errorf("scan timestamp: %w", err)
+	}
+	*s.t = parsed
+	return nil
+}
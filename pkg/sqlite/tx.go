@@ -0,0 +1,81 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+const defaultTxAttempts = 3
+
+// DBTX is the part of *sql.DB and *sql.Tx used by repositories.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type txKey struct{}
+
+// Conn returns the transaction carried by ctx, or db when the call is
+// not part of a unit of work.
+func Conn(ctx context.Context, db *sql.DB) DBTX {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// Transactor runs units of work in SQLite transactions, retrying when
+// the database stays locked by another writer past busy_timeout.
+type Transactor struct {
+	db       *sql.DB
+	attempts int
+}
+
+func NewTransactor(db *sql.DB) *Transactor {
+	return &Transactor{db: db, attempts: defaultTxAttempts}
+}
+
+// Do runs fn in a transaction stored in the context passed to fn.
+// Nested calls join the outer transaction.
+func (t *Transactor) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	var err error
+	for attempt := 0; attempt < t.attempts; attempt++ {
+		err = t.run(ctx, fn)
+		if !IsBusy(err) {
+			return err
+		}
+	}
+	return fmt.Errorf("transaction failed after %d attempts: %w", t.attempts, err)
+}
+
+func (t *Transactor) run(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// IsBusy reports whether err is SQLITE_BUSY or one of its extended codes.
+func IsBusy(err error) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code()&0xff == sqlite3.SQLITE_BUSY
+}
@@ -0,0 +1,64 @@
+package sqlite_test
+
+import (
+	"bulletin-board/pkg/sqlite"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestTransactor(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlite.NewClient(ctx, filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	if _, err := db.ExecContext(ctx, `create table items (name text not null)`); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := sqlite.NewTransactor(db)
+	insert := func(ctx context.Context, name string) error {
+		_, err := sqlite.Conn(ctx, db).ExecContext(ctx, `insert into items (name) values (?)`, name)
+		return err
+	}
+	count := func() int {
+		var n int
+		if err := db.QueryRowContext(ctx, `select count(*) from items`).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	errBoom := errors.New("boom")
+	err = tx.Do(ctx, func(ctx context.Context) error {
+		if err := insert(ctx, "a"); err != nil {
+			return err
+		}
+		return errBoom
+	})
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("Do error = %v, want %v", err, errBoom)
+	}
+	if n := count(); n != 0 {
+		t.Fatalf("rolled back transaction left %d rows", n)
+	}
+
+	err = tx.Do(ctx, func(ctx context.Context) error {
+		if err := insert(ctx, "a"); err != nil {
+			return err
+		}
+		return tx.Do(ctx, func(ctx context.Context) error {
+			return insert(ctx, "b")
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := count(); n != 2 {
+		t.Fatalf("committed transaction has %d rows, want 2", n)
+	}
+}